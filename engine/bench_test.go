@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"fmt"
+	"image/color"
+	"math/rand/v2"
+	"testing"
+)
+
+// reseedEvery is how many generations a benchmark soup runs before it is
+// replaced, so that births stay frequent instead of dying down to ash.
+const reseedEvery = 32
+
+// soup returns a board where each cell is alive with the given probability.
+// The same seed always produces the same soup.
+func soup(width, height int, density float64, seed uint64) ([][]uint8, [][]color.RGBA) {
+	rng := rand.New(rand.NewPCG(seed, seed))
+	cells := makeGrid[uint8](width, height)
+	colors := makeGrid[color.RGBA](width, height)
+	for y := range cells {
+		for x := range cells[y] {
+			if rng.Float64() < density {
+				cells[y][x] = 1
+				colors[y][x] = randomColorFrom(rng)
+			}
+		}
+	}
+	return cells, colors
+}
+
+// benchmarkStep measures Step on soups of the given size and density.
+func benchmarkStep(b *testing.B, width, height int, density float64, scheme ColorScheme) {
+	u := NewUniverse(width, height)
+	u.SetColorScheme(scheme)
+	u.Load(soup(width, height, density, 1))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if i > 0 && i%reseedEvery == 0 {
+			b.StopTimer()
+			u.Load(soup(width, height, density, uint64(i)))
+			b.StartTimer()
+		}
+		u.Step()
+	}
+	b.ReportMetric(float64(width*height)*float64(b.N)/b.Elapsed().Seconds(), "cells/s")
+}
+
+// BenchmarkStepSoup measures tick throughput on high-birth random soups of increasing size.
+func BenchmarkStepSoup(b *testing.B) {
+	for _, size := range []int{128, 256, 512, 1024} {
+		b.Run(fmt.Sprintf("%dx%d", size, size), func(b *testing.B) {
+			benchmarkStep(b, size, size, 0.35, RandomScheme{})
+		})
+	}
+}
+
+// BenchmarkStepDensity measures how tick time depends on the number of births.
+func BenchmarkStepDensity(b *testing.B) {
+	for _, density := range []float64{0, 0.1, 0.35, 0.5} {
+		b.Run(fmt.Sprintf("%.2f", density), func(b *testing.B) {
+			benchmarkStep(b, 512, 512, density, RandomScheme{})
+		})
+	}
+}
+
+// BenchmarkStepScheme measures the cost of each color scheme's birth coloring.
+func BenchmarkStepScheme(b *testing.B) {
+	for _, scheme := range ColorSchemes() {
+		b.Run(scheme.Name(), func(b *testing.B) {
+			benchmarkStep(b, 512, 512, 0.35, scheme)
+		})
+	}
+}
+
+// BenchmarkStepSparse measures a single glider crossing boards of increasing
+// size, where almost every tile is quiet and should cost next to nothing.
+func BenchmarkStepSparse(b *testing.B) {
+	glider, err := ClipFromRLE("x = 3, y = 3\nbo$2bo$3o!")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, size := range []int{256, 1024, 4096} {
+		b.Run(fmt.Sprintf("%dx%d", size, size), func(b *testing.B) {
+			u := NewUniverse(size, size)
+			u.Stamp(glider, size/2, size/2)
+			u.Step() // The first generation after an edit computes every tile
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				u.Step()
+			}
+		})
+	}
+}
+
+// BenchmarkStepRange measures Larger than Life rules of increasing range,
+// whose cost should hardly depend on the range thanks to the summed-area table.
+func BenchmarkStepRange(b *testing.B) {
+	for _, rulestring := range []string{
+		"R1,C0,M0,S2..3,B3,NM",
+		"R5,C0,M1,S34..58,B34..45,NM",
+		"R10,C0,M1,S122..211,B123..170,NM",
+		"R10,C0,M1,S60..110,B62..90,NN",
+	} {
+		rule, err := ParseRule(rulestring)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(rulestring, func(b *testing.B) {
+			u := NewUniverse(512, 512)
+			u.SetRule(rule)
+			u.Load(soup(512, 512, 0.5, 1))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				u.Step()
+			}
+			b.ReportMetric(float64(512*512)*float64(b.N)/b.Elapsed().Seconds(), "cells/s")
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package engine
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// phasedRule is implemented by rules that cycle through phases, applying a
+// different update in each generation. The universe uses the phase of the
+// generation it computes, which is the generation number modulo the number
+// of phases.
+type phasedRule interface {
+	Rule
+	phases() int
+	nextInPhase(cells [][]uint8, x, y, phase int) uint8
+}
+
+// rulePhases returns the number of phases a rule cycles through, 1 for
+// rules that apply the same update every generation.
+func rulePhases(rule Rule) int {
+	if r, ok := baseRule(rule).(phasedRule); ok {
+		return r.phases()
+	}
+	return 1
+}
+
+// BoardSize returns the size of the board a universe of the given size has
+// under a rule. Rules with phases work on 2x2 blocks that have to tile the
+// board in every phase, so their boards are rounded down to an even width
+// and height.
+func BoardSize(rule Rule, width, height int) (int, int) {
+	if rulePhases(rule) > 1 {
+		width, height = max(width&^1, 2), max(height&^1, 2)
+	}
+	return width, height
+}
+
+// BlockRule is a block cellular automaton on the Margolus neighborhood. The
+// board is split into 2x2 blocks, each of which is replaced as a whole
+// according to a table, and the blocks shift by one cell diagonally every
+// generation so that information crosses their borders. Rules whose tables
+// are permutations, such as the billiard ball machine and Critters, are
+// reversible. Blocks wrap around the edges of the board, which BoardSize
+// keeps even in both directions.
+type BlockRule struct {
+	name   string
+	states int
+	// table maps every block to the next one. A block is numbered by
+	// reading its cells as the digits of a base states number, least
+	// significant first: upper left, upper right, lower left, lower right.
+	table []uint16
+}
+
+// parseMargolus parses a two-state block rule in the MCell notation "MS,D"
+// followed by the next block for each of the 16 blocks, separated by
+// semicolons, with the upper left cell worth 1, the upper right 2, the lower
+// left 4 and the lower right 8. The billiard ball machine is
+// "MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14;15".
+func parseMargolus(s string) (*BlockRule, error) {
+	if !strings.HasPrefix(strings.ToUpper(s), "MS,D") {
+		return nil, fmt.Errorf("block rules must start with 'MS,D'")
+	}
+	entries := strings.Split(s[4:], ";")
+	if len(entries) != 16 {
+		return nil, fmt.Errorf("block rules need 16 entries, got %d", len(entries))
+	}
+	r := &BlockRule{states: 2, table: make([]uint16, 16)}
+	for i, entry := range entries {
+		next, err := strconv.Atoi(entry)
+		if err != nil || next < 0 || next > 15 {
+			return nil, fmt.Errorf("invalid block '%s'", entry)
+		}
+		r.table[i] = uint16(next)
+	}
+	texts := make([]string, len(r.table))
+	for i, next := range r.table {
+		texts[i] = strconv.Itoa(int(next))
+	}
+	r.name = "MS,D" + strings.Join(texts, ";")
+	return r, nil
+}
+
+func (r *BlockRule) String() string { return r.name }
+
+func (r *BlockRule) States() int { return r.states }
+
+// Neighborhood returns Moore, which covers the blocks of a cell in every
+// phase.
+func (r *BlockRule) Neighborhood() *Neighborhood { return Moore }
+
+// Next applies the rule with the blocks in the position of even generations.
+// Universes alternate between both positions.
+func (r *BlockRule) Next(cells [][]uint8, x, y int) uint8 {
+	return r.nextInPhase(cells, x, y, 0)
+}
+
+func (r *BlockRule) phases() int { return 2 }
+
+func (r *BlockRule) nextInPhase(cells [][]uint8, x, y, phase int) uint8 {
+	height, width := len(cells), len(cells[0])
+	// Blocks start at even coordinates in phase 0 and odd ones in phase 1
+	x0, y0 := x-(x-phase)&1, y-(y-phase)&1
+	left, right := wrap(x0, width), wrap(x0+1, width)
+	top, bottom := cells[wrap(y0, height)], cells[wrap(y0+1, height)]
+
+	k := r.states
+	block := int(top[left]) + k*(int(top[right])+k*(int(bottom[left])+k*int(bottom[right])))
+	next := int(r.table[block])
+	for i := (x - x0) + 2*(y-y0); i > 0; i-- {
+		next /= k
+	}
+	return uint8(next % k)
+}
+
+// States of the Sand rule.
+const (
+	SandEmpty uint8 = iota
+	SandGrain
+	SandWall
+)
+
+// Sand is a block rule in which grains fall straight down into empty cells
+// and otherwise slide diagonally down into them, piling up on walls, which
+// never move, and on the bottom edge of the board.
+var Sand Rule = sandRule{newSandRule()}
+
+// sandColors are the colors of the Sand states.
+var sandColors = [3]color.RGBA{
+	SandEmpty: {A: 255},
+	SandGrain: {R: 230, G: 200, B: 110, A: 255},
+	SandWall:  {R: 128, G: 128, B: 128, A: 255},
+}
+
+// sandNames are the names of the Sand states.
+var sandNames = [3]string{"empty", "sand", "wall"}
+
+type sandRule struct{ *BlockRule }
+
+// newSandRule builds the table of the Sand rule.
+func newSandRule() *BlockRule {
+	const k = 3
+	r := &BlockRule{name: "Sand", states: k, table: make([]uint16, k*k*k*k)}
+	for block := range r.table {
+		var c [4]uint8 // Upper left, upper right, lower left, lower right
+		for i, v := 0, block; i < 4; i, v = i+1, v/k {
+			c[i] = uint8(v % k)
+		}
+		// Fall first, then slide past whatever is below
+		for _, col := range [2][2]int{{0, 2}, {1, 3}} {
+			if c[col[0]] == SandGrain && c[col[1]] == SandEmpty {
+				c[col[0]], c[col[1]] = SandEmpty, SandGrain
+			}
+		}
+		for _, diag := range [2][2]int{{0, 3}, {1, 2}} {
+			if c[diag[0]] == SandGrain && c[diag[1]] == SandEmpty {
+				c[diag[0]], c[diag[1]] = SandEmpty, SandGrain
+			}
+		}
+		r.table[block] = uint16(c[0]) + k*(uint16(c[1])+k*(uint16(c[2])+k*uint16(c[3])))
+	}
+	return r
+}
+
+func (r sandRule) Next(cells [][]uint8, x, y int) uint8 {
+	return r.nextInPhase(cells, x, y, 0)
+}
+
+// nextInPhase keeps the blocks that wrap around from the bottom edge of the
+// board to the top as they are, so that the bottom edge is a floor rather
+// than grains falling through to the top.
+func (r sandRule) nextInPhase(cells [][]uint8, x, y, phase int) uint8 {
+	if y0 := y - (y-phase)&1; y0 < 0 || y0+1 >= len(cells) {
+		return cells[y][x]
+	}
+	return r.BlockRule.nextInPhase(cells, x, y, phase)
+}
+
+func (sandRule) stateColor(state uint8) color.RGBA { return sandColors[state] }
+
+func (sandRule) stateName(state uint8) string { return sandNames[state] }
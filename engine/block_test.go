@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBlockRulesReverse(t *testing.T) {
+	const width, height, generations = 64, 48, 100
+	for _, name := range []string{"bbm", "critters"} {
+		t.Run(name, func(t *testing.T) {
+			rule, err := ParseRule(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			forward := rule.(*BlockRule)
+			backward := &BlockRule{states: 2, table: make([]uint16, len(forward.table))}
+			for block, next := range forward.table {
+				backward.table[next] = uint16(block)
+			}
+
+			start := randomCells(rule, width, height, 2)
+			u := NewUniverse(width, height)
+			u.SetRule(rule)
+			u.Load(cloneGrid(start), blankColors(start))
+			for i := 0; i < generations; i++ {
+				u.Step()
+			}
+
+			// Undo the generations in reverse, each in the phase it was computed in
+			cells := snapshot(u)
+			for gen := generations - 1; gen >= 0; gen-- {
+				next := makeGrid[uint8](width, height)
+				for y := range cells {
+					for x := range cells[y] {
+						next[y][x] = backward.nextInPhase(cells, x, y, gen%2)
+					}
+				}
+				cells = next
+			}
+			for y := range start {
+				for x := range start[y] {
+					if cells[y][x] != start[y][x] {
+						t.Fatalf("cell %d, %d is %d after running backwards, want %d", x, y, cells[y][x], start[y][x])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestBoardSize(t *testing.T) {
+	tests := []struct {
+		rule          string
+		width, height int
+		wantW, wantH  int
+	}{
+		{"life", 31, 17, 31, 17},
+		{"bbm", 31, 17, 30, 16},
+		{"critters", 30, 16, 30, 16},
+		{"sand", 1, 1, 2, 2},
+		{"sand", 101, 64, 100, 64},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %dx%d", tt.rule, tt.width, tt.height), func(t *testing.T) {
+			rule, err := ParseRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w, h := BoardSize(rule, tt.width, tt.height); w != tt.wantW || h != tt.wantH {
+				t.Errorf("board of %dx%d, want %dx%d", w, h, tt.wantW, tt.wantH)
+			}
+
+			// Universes crop their board when they change to the rule
+			u := NewUniverse(tt.width, tt.height)
+			u.SetRule(rule)
+			if u.Width() != tt.wantW || u.Height() != tt.wantH {
+				t.Errorf("universe of %dx%d, want %dx%d", u.Width(), u.Height(), tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestSandSettlesOnFloor(t *testing.T) {
+	const width, height = 40, 30
+	cells := makeGrid[uint8](width, height)
+	grains := 0
+	for y, row := range randomCells(Sand, width, height/2, 3) {
+		for x, state := range row {
+			if state == SandGrain {
+				cells[y][x] = SandGrain
+				grains++
+			}
+		}
+	}
+	u := NewUniverse(width, height)
+	u.SetRule(Sand)
+	u.Load(cells, blankColors(cells))
+
+	for gen := 1; gen <= 2*height; gen++ {
+		u.Step()
+		if got := u.Population(); got != grains {
+			t.Fatalf("generation %d: %d grains, want %d", gen, got, grains)
+		}
+	}
+	// Every grain rests on the floor or on another grain
+	for y := 0; y < height-1; y++ {
+		for x := 0; x < width; x++ {
+			if u.State(x, y) == SandGrain && u.State(x, y+1) == SandEmpty {
+				t.Fatalf("grain at %d, %d is floating", x, y)
+			}
+		}
+	}
+	if got := u.RunUntilSettled(10); got.Kind != StillLife {
+		t.Errorf("detected %v, want a still life", got)
+	}
+}
@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Framing of cameras that zoom or follow by themselves.
+const (
+	cameraEase  = 0.1 // Fraction of the way to its target a camera moves each frame
+	fitMargin   = 0.9 // Fraction of the frame fitted cells fill
+	maxFitZoom  = 16  // Most pixels per cell a camera zooms in to when fitting
+	minFitCells = 8   // Fewest cells across a camera zooms in to when fitting
+)
+
+// Camera draws a universe into frames of a fixed size, such as for a video.
+// It keeps the middle of the board in the middle of the frame, or with
+// Follow the live cells, and with Zoom 0 it zooms to fit what it frames.
+// Cameras that follow or fit glide to their target over a few frames rather
+// than jumping, so oscillators and gliders do not shake the picture.
+type Camera struct {
+	Width, Height int     // Size of the frames in pixels
+	Zoom          float64 // Pixels per cell, below 1 to show more cells than pixels; 0 zooms to fit
+	Follow        bool    // Frame the live cells rather than the whole board
+
+	centerX, centerY float64 // Board position in the middle of the frame, in cells
+	zoom             float64 // Pixels per cell of the last frame
+	placed           bool    // Whether a frame was drawn yet
+}
+
+// Frame draws the current generation of the universe, moving the camera
+// towards what it frames first. Cells off the board are left background.
+func (c *Camera) Frame(u *Universe) *image.RGBA {
+	c.aim(u)
+	img := image.NewRGBA(image.Rect(0, 0, c.Width, c.Height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(backgroundColor), image.Point{}, draw.Src)
+
+	// Zoomed out, each pixel shows the first live cell of the cells under it
+	span := max(1, int(math.Ceil(1/c.zoom)))
+	hex := u.rule.Neighborhood().Hexagonal()
+	for py := 0; py < c.Height; py++ {
+		y := int(math.Floor(c.centerY + (float64(py)+0.5-float64(c.Height)/2)/c.zoom))
+		if y < 0 || y >= u.height {
+			continue
+		}
+		for px := 0; px < c.Width; px++ {
+			fx := c.centerX + (float64(px)+0.5-float64(c.Width)/2)/c.zoom
+			if hex {
+				fx -= hexShift(y, u.height)
+			}
+			if col, ok := sampleCells(u, int(math.Floor(fx)), y, span); ok {
+				img.SetRGBA(px, py, col)
+			}
+		}
+	}
+	return img
+}
+
+// aim moves the camera towards the middle of what it frames, and towards
+// the zoom that fits it if it zooms to fit. The first frame goes straight
+// there.
+func (c *Camera) aim(u *Universe) {
+	// The whole board, or the bounding box of the live cells
+	minX, minY, maxX, maxY := 0, 0, u.width-1, u.height-1
+	if c.Follow {
+		s, ok := u.Stats().Latest()
+		if !ok || s.Population == 0 {
+			if !c.placed {
+				c.centerX, c.centerY = float64(u.width)/2, float64(u.height)/2
+				c.zoom = c.fitZoom(u.width, u.height)
+				c.placed = true
+			}
+			return
+		}
+		minX, minY, maxX, maxY = s.MinX, s.MinY, s.MaxX, s.MaxY
+	}
+	targetX := float64(minX+maxX+1) / 2
+	targetY := float64(minY+maxY+1) / 2
+	if u.rule.Neighborhood().Hexagonal() {
+		targetX += hexShift(int(targetY), u.height)
+	}
+	targetZoom := c.fitZoom(maxX-minX+1, maxY-minY+1)
+
+	if !c.placed {
+		c.centerX, c.centerY, c.zoom = targetX, targetY, targetZoom
+		c.placed = true
+		return
+	}
+	c.centerX += (targetX - c.centerX) * cameraEase
+	c.centerY += (targetY - c.centerY) * cameraEase
+	c.zoom *= math.Pow(targetZoom/c.zoom, cameraEase)
+}
+
+// fitZoom returns the zoom of the camera, or if it zooms to fit, the zoom
+// that fits a box of cells of the given size into the frame.
+func (c *Camera) fitZoom(width, height int) float64 {
+	if c.Zoom > 0 {
+		return c.Zoom
+	}
+	width, height = max(width, minFitCells), max(height, minFitCells)
+	zoom := fitMargin * min(float64(c.Width)/float64(width), float64(c.Height)/float64(height))
+	return min(zoom, maxFitZoom)
+}
+
+// hexShift returns how many cells to the right row y of a board of the
+// given height is drawn under hexagonal rules, each row half a cell to the
+// right of the one below.
+func hexShift(y, height int) float64 {
+	return float64(height-1-y) / 2
+}
+
+// sampleCells returns the color of the first live cell of the span by span
+// cells with their top-left corner at (x, y), and false if they are all
+// dead or off the board.
+func sampleCells(u *Universe, x, y, span int) (c color.RGBA, ok bool) {
+	for cy := y; cy < min(y+span, u.height); cy++ {
+		for cx := max(x, 0); cx < min(x+span, u.width); cx++ {
+			if col, ok := u.CellColor(cx, cy); ok {
+				return col, true
+			}
+		}
+	}
+	return c, false
+}
@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+// litBounds returns the box of the pixels of a frame that are not
+// background.
+func litBounds(img *image.RGBA) image.Rectangle {
+	var r image.Rectangle
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			if img.RGBAAt(x, y) != backgroundColor {
+				r = r.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return r
+}
+
+// cameraUniverse returns a monochrome universe with the rows drawn at (x, y).
+func cameraUniverse(width, height, x, y int, rows ...string) *Universe {
+	cells := boardWith(width, height, x, y, rows...)
+	u := NewUniverse(width, height)
+	u.Load(cells, blankColors(cells))
+	u.SetColorScheme(MonochromeScheme{})
+	return u
+}
+
+func TestCameraFixedZoom(t *testing.T) {
+	// The middle of the board sits in the middle of the frame
+	u := cameraUniverse(20, 10, 9, 4, "##", "##")
+	c := Camera{Width: 80, Height: 60, Zoom: 4}
+	if got, want := litBounds(c.Frame(u)), image.Rect(36, 26, 44, 34); got != want {
+		t.Errorf("block drawn at %v, want %v", got, want)
+	}
+}
+
+func TestCameraFit(t *testing.T) {
+	// Zoom 0 fits the whole board into the frame, within the margin
+	u := cameraUniverse(40, 20, 0, 0, "#")
+	u.cells[19][39] = 1
+	c := Camera{Width: 200, Height: 200}
+	img := c.Frame(u)
+	want := fitMargin * 200 / 40
+	if math.Abs(c.zoom-want) > 1e-9 {
+		t.Fatalf("zoom %.2f, want %.2f", c.zoom, want)
+	}
+	got := litBounds(img)
+	if got.Dx() < int(39*want) || got.Dx() > 200 || got.Min.X < 0 {
+		t.Errorf("corners drawn across %v, want the width of the board", got)
+	}
+}
+
+func TestCameraFollow(t *testing.T) {
+	// A following camera keeps the glider near the middle as it flies off,
+	// and glides rather than jumps when it moves
+	u := cameraUniverse(200, 200, 20, 20, glider...)
+	c := Camera{Width: 100, Height: 100, Zoom: 4, Follow: true}
+	first := litBounds(c.Frame(u))
+	if mid := (first.Min.X + first.Max.X) / 2; mid < 44 || mid > 56 {
+		t.Fatalf("glider drawn at %v, want it in the middle", first)
+	}
+	for i := 0; i < 200; i++ {
+		u.Step()
+		before := c.centerX
+		got := litBounds(c.Frame(u))
+		if step := math.Abs(c.centerX - before); step > 1 {
+			t.Fatalf("generation %d: camera jumped %.2f cells", i+1, step)
+		}
+		if got.Empty() || got.Min.X < 10 || got.Max.X > 90 || got.Min.Y < 10 || got.Max.Y > 90 {
+			t.Fatalf("generation %d: glider drawn at %v, out of the middle", i+1, got)
+		}
+	}
+}
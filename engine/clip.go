@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+
+	"github.com/jared-wallace/gol/pkg/PatternParser"
+)
+
+// Clip is a rectangular block of cells lifted off a board, used for the
+// clipboard and for pasting.
+type Clip struct {
+	Width, Height int
+	Cells         [][]uint8 // State of each cell, 0 for dead
+	Colors        [][]color.RGBA
+}
+
+// NewClip creates an empty clip of the given size.
+func NewClip(width, height int) *Clip {
+	c := &Clip{Width: width, Height: height}
+	c.Cells = make([][]uint8, height)
+	c.Colors = make([][]color.RGBA, height)
+	for y := range c.Cells {
+		c.Cells[y] = make([]uint8, width)
+		c.Colors[y] = make([]color.RGBA, width)
+	}
+	return c
+}
+
+// ClipFromRLE parses RLE text, including multi-state RLE, into a clip. Live
+// cells get random colors.
+func ClipFromRLE(text string) (*Clip, error) {
+	coordinates, width, height, err := PatternParser.ReadRLEPattern(strings.NewReader(text))
+	if err != nil {
+		return nil, err
+	}
+	// Trust the cells over the header if they disagree
+	for _, c := range coordinates {
+		if c.X >= width {
+			width = c.X + 1
+		}
+		if c.Y >= height {
+			height = c.Y + 1
+		}
+	}
+
+	clip := NewClip(width, height)
+	for _, c := range coordinates {
+		if c.X >= 0 && c.Y >= 0 {
+			clip.Cells[c.Y][c.X] = uint8(max(c.State, 1))
+			clip.Colors[c.Y][c.X] = randomColor()
+		}
+	}
+	return clip, nil
+}
+
+// RLE encodes the clip as RLE text for the given rule.
+func (c *Clip) RLE(rule Rule) string {
+	var coordinates []PatternParser.Coordinate
+	for y, row := range c.Cells {
+		for x, state := range row {
+			if state != 0 {
+				coordinates = append(coordinates, PatternParser.Coordinate{X: x, Y: y, State: int(state)})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	PatternParser.WriteRLE(&buf, coordinates, c.Width, c.Height, rule.String())
+	return buf.String()
+}
+
+// Clone returns a deep copy of the clip.
+func (c *Clip) Clone() *Clip {
+	clone := NewClip(c.Width, c.Height)
+	for y := 0; y < c.Height; y++ {
+		copy(clone.Cells[y], c.Cells[y])
+		copy(clone.Colors[y], c.Colors[y])
+	}
+	return clone
+}
+
+// Rotate turns the clip 90 degrees clockwise.
+func (c *Clip) Rotate() {
+	rotated := NewClip(c.Height, c.Width)
+	for y := 0; y < c.Height; y++ {
+		for x := 0; x < c.Width; x++ {
+			rotated.Cells[x][c.Height-1-y] = c.Cells[y][x]
+			rotated.Colors[x][c.Height-1-y] = c.Colors[y][x]
+		}
+	}
+	*c = *rotated
+}
+
+// FlipHorizontal mirrors the clip left to right.
+func (c *Clip) FlipHorizontal() {
+	for y := 0; y < c.Height; y++ {
+		for l, r := 0, c.Width-1; l < r; l, r = l+1, r-1 {
+			c.Cells[y][l], c.Cells[y][r] = c.Cells[y][r], c.Cells[y][l]
+			c.Colors[y][l], c.Colors[y][r] = c.Colors[y][r], c.Colors[y][l]
+		}
+	}
+}
+
+// FlipVertical mirrors the clip top to bottom.
+func (c *Clip) FlipVertical() {
+	for t, b := 0, c.Height-1; t < b; t, b = t+1, b-1 {
+		c.Cells[t], c.Cells[b] = c.Cells[b], c.Cells[t]
+		c.Colors[t], c.Colors[b] = c.Colors[b], c.Colors[t]
+	}
+}
+
+// Changes returns the edits that stamp the live cells of the clip with its
+// top-left corner at (x, y). Dead cells of the clip leave the board untouched.
+func (c *Clip) Changes(x, y int) []CellChange {
+	var changes []CellChange
+	for dy, row := range c.Cells {
+		for dx, state := range row {
+			if state != 0 {
+				changes = append(changes, CellChange{X: x + dx, Y: y + dy, State: state, Color: c.Colors[dy][dx]})
+			}
+		}
+	}
+	return changes
+}
+
+// Region copies a rectangle of the board into a clip. Coordinates wrap
+// around the edges of the board.
+func (u *Universe) Region(x, y, width, height int) *Clip {
+	clip := NewClip(width, height)
+	for dy := 0; dy < height; dy++ {
+		for dx := 0; dx < width; dx++ {
+			cx := ((x+dx)%u.width + u.width) % u.width
+			cy := ((y+dy)%u.height + u.height) % u.height
+			clip.Cells[dy][dx] = u.cells[cy][cx]
+			clip.Colors[dy][dx] = u.colors[cy][cx]
+		}
+	}
+	return clip
+}
@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// clipboardTimeout bounds how long a clipboard program may run.
+const clipboardTimeout = 2 * time.Second
+
+// clipboardRead is the outcome of reading the system clipboard.
+type clipboardRead struct {
+	text string
+	err  error
+}
+
+// systemClipboard runs clipboard programs in the background, so that a slow
+// or hanging one never stalls the game loop. Reads wait for earlier writes,
+// so pasting right after copying sees the copied text.
+type systemClipboard struct {
+	written <-chan struct{}      // Closed once the latest write has finished
+	reading <-chan clipboardRead // Pending read, nil if none
+}
+
+// write starts putting text on the system clipboard. Failures are logged.
+func (c *systemClipboard) write(text string) {
+	previous := c.written
+	done := make(chan struct{})
+	c.written = done
+	go func() {
+		defer close(done)
+		if previous != nil {
+			<-previous
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), clipboardTimeout)
+		defer cancel()
+		if err := writeSystemClipboard(ctx, text); err != nil {
+			log.Printf("Copied to internal clipboard only: %v", err)
+		}
+	}()
+}
+
+// read starts reading the system clipboard, unless a read is already pending.
+func (c *systemClipboard) read() {
+	if c.reading != nil {
+		return
+	}
+	written := c.written
+	result := make(chan clipboardRead, 1)
+	c.reading = result
+	go func() {
+		if written != nil {
+			<-written
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), clipboardTimeout)
+		defer cancel()
+		text, err := readSystemClipboard(ctx)
+		result <- clipboardRead{text: text, err: err}
+	}()
+}
+
+// poll returns the text of the pending read once it has finished. done is
+// false while the read is still running or if none was started.
+func (c *systemClipboard) poll() (text string, done bool, err error) {
+	select {
+	case r := <-c.reading:
+		c.reading = nil
+		return r.text, true, r.err
+	default:
+		return "", false, nil
+	}
+}
+
+// clipboardCommand describes an external program used to access the system clipboard.
+type clipboardCommand struct {
+	name string
+	args []string
+}
+
+// clipboardCommands returns the candidate copy and paste programs for the current platform.
+func clipboardCommands() (copyCmds, pasteCmds []clipboardCommand) {
+	switch runtime.GOOS {
+	case "darwin":
+		return []clipboardCommand{{name: "pbcopy"}},
+			[]clipboardCommand{{name: "pbpaste"}}
+	case "windows":
+		return []clipboardCommand{{name: "clip"}},
+			[]clipboardCommand{{name: "powershell", args: []string{"-NoProfile", "-Command", "Get-Clipboard -Raw"}}}
+	default:
+		return []clipboardCommand{
+				{name: "wl-copy"},
+				{name: "xclip", args: []string{"-selection", "clipboard"}},
+				{name: "xsel", args: []string{"--clipboard", "--input"}},
+			},
+			[]clipboardCommand{
+				{name: "wl-paste", args: []string{"--no-newline"}},
+				{name: "xclip", args: []string{"-selection", "clipboard", "-o"}},
+				{name: "xsel", args: []string{"--clipboard", "--output"}},
+			}
+	}
+}
+
+// writeSystemClipboard puts text on the system clipboard using the first
+// clipboard program found on the PATH. The program is killed when ctx is done.
+func writeSystemClipboard(ctx context.Context, text string) error {
+	copyCmds, _ := clipboardCommands()
+	for _, c := range copyCmds {
+		if _, err := exec.LookPath(c.name); err != nil {
+			continue
+		}
+		cmd := exec.CommandContext(ctx, c.name, c.args...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to run %s: %v", c.name, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("no clipboard program found")
+}
+
+// readSystemClipboard returns the text on the system clipboard using the
+// first clipboard program found on the PATH. The program is killed when ctx
+// is done.
+func readSystemClipboard(ctx context.Context) (string, error) {
+	_, pasteCmds := clipboardCommands()
+	for _, c := range pasteCmds {
+		if _, err := exec.LookPath(c.name); err != nil {
+			continue
+		}
+		out, err := exec.CommandContext(ctx, c.name, c.args...).Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to run %s: %v", c.name, err)
+		}
+		return string(out), nil
+	}
+	return "", fmt.Errorf("no clipboard program found")
+}
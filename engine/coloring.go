@@ -0,0 +1,228 @@
+package engine
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"math/rand/v2"
+	"strings"
+)
+
+// maxHeat is the activity level of a cell that changed in the latest generation.
+const maxHeat = 255
+
+// CellInfo describes a cell for a color scheme.
+type CellInfo struct {
+	Alive bool       // The cell is in any state but dead
+	State uint8      // State of the cell, 1 for live cells of two-state rules
+	Age   int        // Generations the cell has been alive, 0 for newborns
+	Heat  uint8      // Recent activity: maxHeat when the cell just changed, decaying every generation
+	Color color.RGBA // Color given to the cell when it was born
+}
+
+// Born reports whether the cell came alive in the latest generation.
+func (c CellInfo) Born() bool {
+	return c.Alive && c.Heat == maxHeat
+}
+
+// Died reports whether the cell died in the latest generation.
+func (c CellInfo) Died() bool {
+	return !c.Alive && c.Heat == maxHeat
+}
+
+// ColorScheme decides how cells are colored. Birth is called by the tick
+// workers concurrently, so implementations must be safe for concurrent use;
+// any randomness must come from the rng passed in, which belongs to the
+// calling worker.
+type ColorScheme interface {
+	// Name identifies the scheme on the command line and in the HUD.
+	Name() string
+	// Birth returns the color stored for a newborn cell, given the stored
+	// colors of its live neighbors. Parents is empty for cells set by hand.
+	Birth(parents []color.RGBA, rng *rand.Rand) color.RGBA
+	// CellColor returns the display color of a cell, or false if the cell
+	// should not be drawn.
+	CellColor(c CellInfo) (color.RGBA, bool)
+}
+
+// ColorSchemes returns every available color scheme, in the order the GUI cycles through them.
+func ColorSchemes() []ColorScheme {
+	return []ColorScheme{
+		RandomScheme{},
+		AgeScheme{},
+		BirthDeathScheme{},
+		HeatScheme{},
+		MonochromeScheme{},
+		InheritMajorityScheme{},
+		InheritAverageScheme{},
+		ImmigrationScheme,
+		QuadLifeScheme,
+	}
+}
+
+// Recolorer is implemented by color schemes that restrict cells to a fixed
+// palette. The universe passes the stored color of every live cell through
+// Recolor when such a scheme is selected, a board is loaded or cells are set
+// by hand.
+type Recolorer interface {
+	Recolor(c color.RGBA) color.RGBA
+}
+
+// ColorSchemeByName returns the color scheme with the given name.
+func ColorSchemeByName(name string) (ColorScheme, error) {
+	var names []string
+	for _, s := range ColorSchemes() {
+		if s.Name() == name {
+			return s, nil
+		}
+		names = append(names, s.Name())
+	}
+	return nil, fmt.Errorf("unknown color scheme '%s' (available: %s)", name, strings.Join(names, ", "))
+}
+
+// RandomScheme gives every newborn cell a random color that it keeps for life.
+type RandomScheme struct{}
+
+func (RandomScheme) Name() string                                    { return "random" }
+func (RandomScheme) Birth(_ []color.RGBA, rng *rand.Rand) color.RGBA { return randomColorFrom(rng) }
+
+func (RandomScheme) CellColor(c CellInfo) (color.RGBA, bool) {
+	return c.Color, c.Alive
+}
+
+// ageGradient runs from newborn to old cells.
+var ageGradient = []color.RGBA{
+	{R: 255, G: 255, B: 160, A: 255},
+	{R: 255, G: 160, B: 0, A: 255},
+	{R: 220, G: 40, B: 60, A: 255},
+	{R: 120, G: 0, B: 160, A: 255},
+	{R: 40, G: 40, B: 200, A: 255},
+}
+
+// ageGradientSpan is the age at which cells reach the last gradient color.
+const ageGradientSpan = 100
+
+// AgeScheme colors live cells along a gradient from young to old.
+type AgeScheme struct{}
+
+func (AgeScheme) Name() string                              { return "age" }
+func (AgeScheme) Birth([]color.RGBA, *rand.Rand) color.RGBA { return ageGradient[0] }
+
+func (AgeScheme) CellColor(c CellInfo) (color.RGBA, bool) {
+	if !c.Alive {
+		return color.RGBA{}, false
+	}
+	return gradient(ageGradient, float64(min(c.Age, ageGradientSpan))/ageGradientSpan), true
+}
+
+var (
+	birthColor    = color.RGBA{R: 60, G: 220, B: 60, A: 255}
+	deathColor    = color.RGBA{R: 200, G: 40, B: 40, A: 255}
+	survivorColor = color.RGBA{R: 160, G: 160, B: 160, A: 255}
+)
+
+// BirthDeathScheme highlights cells born and cells that died in the latest generation.
+type BirthDeathScheme struct{}
+
+func (BirthDeathScheme) Name() string                              { return "births-deaths" }
+func (BirthDeathScheme) Birth([]color.RGBA, *rand.Rand) color.RGBA { return birthColor }
+
+func (BirthDeathScheme) CellColor(c CellInfo) (color.RGBA, bool) {
+	switch {
+	case c.Born():
+		return birthColor, true
+	case c.Died():
+		return deathColor, true
+	case c.Alive:
+		return survivorColor, true
+	default:
+		return color.RGBA{}, false
+	}
+}
+
+// heatGradient runs from cold to hot.
+var heatGradient = []color.RGBA{
+	{R: 40, G: 0, B: 0, A: 255},
+	{R: 200, G: 0, B: 0, A: 255},
+	{R: 255, G: 160, B: 0, A: 255},
+	{R: 255, G: 255, B: 200, A: 255},
+}
+
+// stableColor is used by the heat map for live cells with no recent activity.
+var stableColor = color.RGBA{R: 60, G: 60, B: 110, A: 255}
+
+// HeatScheme shows where the board has recently changed, live or dead.
+type HeatScheme struct{}
+
+func (HeatScheme) Name() string { return "heat" }
+func (HeatScheme) Birth([]color.RGBA, *rand.Rand) color.RGBA {
+	return heatGradient[len(heatGradient)-1]
+}
+
+func (HeatScheme) CellColor(c CellInfo) (color.RGBA, bool) {
+	if c.Heat == 0 {
+		return stableColor, c.Alive
+	}
+	return gradient(heatGradient, float64(c.Heat)/maxHeat), true
+}
+
+// MonochromeScheme draws every live cell in white.
+type MonochromeScheme struct{}
+
+var monochromeColor = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+func (MonochromeScheme) Name() string                              { return "monochrome" }
+func (MonochromeScheme) Birth([]color.RGBA, *rand.Rand) color.RGBA { return monochromeColor }
+
+func (MonochromeScheme) CellColor(c CellInfo) (color.RGBA, bool) {
+	return monochromeColor, c.Alive
+}
+
+// fade darkens c by the given number of steps out of total, for cells in the
+// refractory states of multi-state rules.
+func fade(c color.RGBA, step, total int) color.RGBA {
+	f := func(v uint8) uint8 {
+		return uint8(int(v) * (total - step) / total)
+	}
+	return color.RGBA{R: f(c.R), G: f(c.G), B: f(c.B), A: c.A}
+}
+
+// gradient returns the color at position t (0 to 1) along evenly spaced stops.
+func gradient(stops []color.RGBA, t float64) color.RGBA {
+	if t <= 0 {
+		return stops[0]
+	}
+	if t >= 1 {
+		return stops[len(stops)-1]
+	}
+	pos := t * float64(len(stops)-1)
+	i := int(pos)
+	f := pos - float64(i)
+	a, b := stops[i], stops[i+1]
+	lerp := func(x, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*f)
+	}
+	return color.RGBA{R: lerp(a.R, b.R), G: lerp(a.G, b.G), B: lerp(a.B, b.B), A: 255}
+}
+
+// neverChanged marks a cell with no recent activity.
+const neverChanged = math.MinInt32
+
+// heatDecay holds the heat of a cell the given number of generations after
+// it changed, losing an eighth every generation until it reaches zero.
+var heatDecay = func() []uint8 {
+	decay := []uint8{maxHeat}
+	for h := decay[0]; h > 0; {
+		h = uint8(uint16(h) * 7 / 8)
+		decay = append(decay, h)
+	}
+	return decay
+}()
+
+// heatAfter returns the heat of a cell that changed the given number of generations ago.
+func heatAfter(generations int) uint8 {
+	if generations < 0 || generations >= len(heatDecay) {
+		return 0
+	}
+	return heatDecay[generations]
+}
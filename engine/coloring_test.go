@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestSchemeBirthColors(t *testing.T) {
+	// Newborn cells get the color their scheme gives them at birth, and keep
+	// it while they survive
+	for _, scheme := range []ColorScheme{AgeScheme{}, BirthDeathScheme{}, HeatScheme{}, MonochromeScheme{}} {
+		t.Run(scheme.Name(), func(t *testing.T) {
+			u := NewUniverse(16, 16)
+			cells := boardWith(16, 16, 5, 5, "##", "#.")
+			u.Load(cells, blankColors(cells))
+			u.SetColorScheme(scheme)
+			u.Step()
+			if !u.Alive(6, 6) {
+				t.Fatal("no cell was born at 6, 6")
+			}
+			if got := u.Color(6, 6); got != scheme.Birth(nil, nil) {
+				t.Errorf("newborn cell colored %v, want %v", got, scheme.Birth(nil, nil))
+			}
+			if got := u.Color(5, 5); got != (color.RGBA{}) {
+				t.Errorf("surviving cell recolored to %v", got)
+			}
+		})
+	}
+}
+
+func TestCellColors(t *testing.T) {
+	oldColor := color.RGBA{R: 1, G: 2, B: 3, A: 255}
+	tests := []struct {
+		name   string
+		scheme ColorScheme
+		cell   CellInfo
+		want   color.RGBA
+		drawn  bool
+	}{
+		{"random keeps the birth color", RandomScheme{}, CellInfo{Alive: true, Age: 5, Color: oldColor}, oldColor, true},
+		{"random skips dead cells", RandomScheme{}, CellInfo{Color: oldColor}, color.RGBA{}, false},
+		{"age of a newborn", AgeScheme{}, CellInfo{Alive: true, Heat: maxHeat}, ageGradient[0], true},
+		{"age at the end of the gradient", AgeScheme{}, CellInfo{Alive: true, Age: ageGradientSpan}, ageGradient[len(ageGradient)-1], true},
+		{"age past the end of the gradient", AgeScheme{}, CellInfo{Alive: true, Age: 10 * ageGradientSpan}, ageGradient[len(ageGradient)-1], true},
+		{"age skips dead cells", AgeScheme{}, CellInfo{Heat: maxHeat}, color.RGBA{}, false},
+		{"birth", BirthDeathScheme{}, CellInfo{Alive: true, Heat: maxHeat}, birthColor, true},
+		{"death", BirthDeathScheme{}, CellInfo{Heat: maxHeat}, deathColor, true},
+		{"survivor", BirthDeathScheme{}, CellInfo{Alive: true, Age: 3, Heat: heatAfter(1)}, survivorColor, true},
+		{"long dead", BirthDeathScheme{}, CellInfo{Heat: heatAfter(1)}, color.RGBA{}, false},
+		{"hottest", HeatScheme{}, CellInfo{Heat: maxHeat}, heatGradient[len(heatGradient)-1], true},
+		{"stable live cell", HeatScheme{}, CellInfo{Alive: true, Age: 50}, stableColor, true},
+		{"stable dead cell", HeatScheme{}, CellInfo{}, stableColor, false},
+		{"monochrome", MonochromeScheme{}, CellInfo{Alive: true, Color: oldColor}, monochromeColor, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, drawn := tt.scheme.CellColor(tt.cell)
+			if drawn != tt.drawn || (drawn && got != tt.want) {
+				t.Errorf("colored %v, drawn %t, want %v, drawn %t", got, drawn, tt.want, tt.drawn)
+			}
+		})
+	}
+}
+
+func TestColorSchemeByName(t *testing.T) {
+	for _, scheme := range ColorSchemes() {
+		got, err := ColorSchemeByName(scheme.Name())
+		if err != nil || got != scheme {
+			t.Errorf("%s: got %v, %v", scheme.Name(), got, err)
+		}
+	}
+	if _, err := ColorSchemeByName("sepia"); err == nil {
+		t.Error("unknown scheme accepted")
+	}
+}
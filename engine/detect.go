@@ -0,0 +1,343 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// DefaultMaxPeriod is the number of past generations the detector remembers,
+// which bounds the longest period it can recognise.
+const DefaultMaxPeriod = 1024
+
+// DetectionKind classifies the long-term behaviour of a universe.
+type DetectionKind int
+
+const (
+	Evolving   DetectionKind = iota // No repeat seen yet
+	Extinct                         // Every cell is dead
+	StillLife                       // The board no longer changes
+	Oscillator                      // The board repeats in place
+	Spaceship                       // The board repeats displaced
+)
+
+// String returns a human readable name for the kind.
+func (k DetectionKind) String() string {
+	switch k {
+	case Extinct:
+		return "extinct"
+	case StillLife:
+		return "still life"
+	case Oscillator:
+		return "oscillator"
+	case Spaceship:
+		return "spaceship"
+	default:
+		return "evolving"
+	}
+}
+
+// Detection is the result of period and stabilization detection.
+type Detection struct {
+	Kind       DetectionKind
+	Period     int // Generations between repeats, 0 while evolving or extinct
+	DX, DY     int // Displacement per period, only set for spaceships
+	Generation int // Generation at which this behaviour was first detected
+}
+
+// Settled reports whether the universe has stopped evolving in an
+// interesting way, i.e. it died out or repeats itself.
+func (d Detection) Settled() bool {
+	return d.Kind != Evolving
+}
+
+// String formats the detection for display in the HUD.
+func (d Detection) String() string {
+	switch d.Kind {
+	case Extinct:
+		return fmt.Sprintf("extinct at gen %d", d.Generation)
+	case StillLife:
+		return fmt.Sprintf("still life since gen %d", d.Generation)
+	case Oscillator:
+		return fmt.Sprintf("oscillator p%d since gen %d", d.Period, d.Generation)
+	case Spaceship:
+		return fmt.Sprintf("spaceship p%d (%d,%d) since gen %d", d.Period, d.DX, d.DY, d.Generation)
+	default:
+		return "evolving"
+	}
+}
+
+// DefaultMaxShapeBytes bounds the memory the detector spends on copies of
+// the shapes it remembers. On large, busy boards it limits the longest
+// period that can be recognised more tightly than DefaultMaxPeriod.
+const DefaultMaxShapeBytes = 64 << 20
+
+// sighting records where and when a board shape was observed.
+type sighting struct {
+	generation int
+	minX, minY int
+	shape      []byte // The box as encodeBox encodes it, to tell repeats from hash collisions
+}
+
+// Detector hashes every generation and recognises repeats. Boards are hashed
+// relative to their bounding box, so a repeat at a different offset is
+// reported as a spaceship and a repeat at the same offset as an oscillator.
+// A hash only suggests a repeat, which a copy of the shape then confirms.
+type Detector struct {
+	maxPeriod int
+	maxBytes  int                   // Bound on the size of the shapes kept
+	phases    int                   // Phases of the rule, see SetPhases
+	previous  sighting              // The latest generation
+	seen      map[uint64][]sighting // Shape hash -> sightings, oldest first
+	order     []uint64              // Shape hashes in the order they were observed
+	bytes     int                   // Size of the shapes of all sightings, shared ones counted for each
+	result    Detection
+	buf       []byte // The shape of the latest generation, reused
+}
+
+// NewDetector creates a detector that recognises periods up to maxPeriod.
+func NewDetector(maxPeriod int) *Detector {
+	d := &Detector{maxPeriod: maxPeriod, maxBytes: DefaultMaxShapeBytes, phases: 1}
+	d.Reset()
+	return d
+}
+
+// Reset forgets all previously observed generations.
+func (d *Detector) Reset() {
+	d.seen = make(map[uint64][]sighting)
+	d.order = d.order[:0]
+	d.bytes = 0
+	d.result = Detection{}
+	d.previous = sighting{}
+}
+
+// SetPhases tells the detector that the rule cycles through the given
+// number of phases. Boards then only match if they were seen in the same
+// phase and with their bounding box at the same position modulo the number
+// of phases, which for block rules keeps the blocks aligned.
+func (d *Detector) SetPhases(phases int) {
+	d.phases = phases
+}
+
+// Result returns the latest detection result.
+func (d *Detector) Result() Detection {
+	return d.result
+}
+
+// Observe records the board for the given generation and returns the
+// updated detection result.
+func (d *Detector) Observe(cells [][]uint8, generation int) Detection {
+	minX, minY, maxX, maxY, population := boundingBox(cells)
+	return d.observe(cells, minX, minY, maxX, maxY, population, generation)
+}
+
+// ObserveStats is like Observe, but takes the population and bounding box of
+// the board from its statistics instead of scanning the whole board.
+func (d *Detector) ObserveStats(cells [][]uint8, s Stats) Detection {
+	return d.observe(cells, s.MinX, s.MinY, s.MaxX, s.MaxY, s.Population, s.Generation)
+}
+
+// observe records a generation given the bounding box and population of its
+// live cells.
+func (d *Detector) observe(cells [][]uint8, minX, minY, maxX, maxY, population, generation int) Detection {
+	width, height := len(cells[0]), len(cells)
+	var hash uint64
+	var shape []byte
+	if population > 0 {
+		d.buf = encodeBox(d.buf[:0], cells, minX, minY, maxX, maxY)
+		shape = d.buf
+		hash = hashBytes(shape)
+		if d.phases > 1 {
+			for _, v := range [3]int{generation, minX, minY} {
+				hash = (hash ^ uint64(v%d.phases)) * fnvPrime
+			}
+		}
+	}
+
+	var next Detection
+	var last sighting
+	found := false
+	if population == 0 {
+		next = Detection{Kind: Extinct}
+	} else if last, found = d.lastSighting(hash, shape); found {
+		next = Detection{
+			Period: generation - last.generation,
+			DX:     wrapDelta(minX-last.minX, width),
+			DY:     wrapDelta(minY-last.minY, height),
+		}
+		switch {
+		case next.DX != 0 || next.DY != 0:
+			next.Kind = Spaceship
+		case next.Period == 1 || next.Period == d.phases && d.unchanged(minX, minY, shape):
+			// Unchanged in every phase
+			next.Kind = StillLife
+		default:
+			next.Kind = Oscillator
+		}
+		if next.Kind == StillLife {
+			next.Period = 0
+		}
+	}
+
+	// A shape that wraps around an edge has a box as wide or as tall as the
+	// board, which tells nothing of where it moved, and once across it only
+	// matches sightings several periods back. Neither changes what was seen.
+	wraps := maxX-minX+1 == width || maxY-minY+1 == height
+	switch {
+	case wraps && (next.Kind == Evolving || next.Kind == Spaceship):
+		next = d.result
+	case d.result.confirmedBy(next, width, height):
+		next = d.result
+	}
+
+	// Keep the generation of the first detection while the behaviour persists
+	next.Generation = generation
+	if next.Kind == d.result.Kind && next.Period == d.result.Period && next.DX == d.result.DX && next.DY == d.result.DY {
+		next.Generation = d.result.Generation
+	}
+	if next.Kind == Evolving {
+		next.Generation = 0
+	}
+	d.result = next
+
+	// A confirmed repeat shares the copy of the shape it repeats
+	if found {
+		shape = last.shape
+	} else {
+		shape = append([]byte(nil), shape...)
+	}
+	d.previous = sighting{generation: generation, minX: minX, minY: minY, shape: shape}
+	d.seen[hash] = append(d.seen[hash], d.previous)
+	d.order = append(d.order, hash)
+	d.bytes += len(shape)
+	for len(d.order) > 1 && (len(d.order) > d.maxPeriod || d.bytes > d.maxBytes) {
+		oldest := d.order[0]
+		d.order = d.order[1:]
+		past := d.seen[oldest]
+		d.bytes -= len(past[0].shape)
+		if len(past) > 1 {
+			d.seen[oldest] = past[1:]
+		} else {
+			delete(d.seen, oldest)
+		}
+	}
+
+	return d.result
+}
+
+// lastSighting returns the most recent sighting of the shape, which gives
+// the smallest period. Sightings with the same hash but another shape are
+// collisions and skipped.
+func (d *Detector) lastSighting(hash uint64, shape []byte) (sighting, bool) {
+	past := d.seen[hash]
+	for i := len(past) - 1; i >= 0; i-- {
+		if bytes.Equal(past[i].shape, shape) {
+			return past[i], true
+		}
+	}
+	return sighting{}, false
+}
+
+// unchanged reports whether the shape at the given position is the same as
+// in the latest generation.
+func (d *Detector) unchanged(minX, minY int, shape []byte) bool {
+	p := d.previous
+	return p.minX == minX && p.minY == minY && bytes.Equal(p.shape, shape)
+}
+
+// confirmedBy reports whether a repeat is the behaviour already detected
+// seen over several periods, as when a spaceship comes back from across an
+// edge and matches a sighting from before it wrapped, or has gone all the
+// way around the board.
+func (d Detection) confirmedBy(next Detection, width, height int) bool {
+	repeats := func(k DetectionKind) bool { return k == Oscillator || k == Spaceship }
+	if !repeats(d.Kind) || !repeats(next.Kind) || next.Period%d.Period != 0 {
+		return false
+	}
+	periods := next.Period / d.Period
+	return next.DX == wrapDelta(periods*d.DX, width) && next.DY == wrapDelta(periods*d.DY, height)
+}
+
+// wrapDelta returns the shortest displacement along an axis of the given
+// size that is equivalent to delta on the torus.
+func wrapDelta(delta, size int) int {
+	delta %= size
+	switch {
+	case delta > size/2:
+		delta -= size
+	case delta <= -size/2:
+		delta += size
+	}
+	return delta
+}
+
+// FNV-1a parameters used by hashBytes.
+const (
+	fnvOffset uint64 = 14695981039346656037
+	fnvPrime  uint64 = 1099511628211
+)
+
+// hashBytes hashes a shape with FNV-1a.
+func hashBytes(b []byte) uint64 {
+	hash := fnvOffset
+	for _, c := range b {
+		hash = (hash ^ uint64(c)) * fnvPrime
+	}
+	return hash
+}
+
+// boundingBox returns the bounding box of the live cells of the board and
+// their number. The box is all zeros if there are none.
+func boundingBox(cells [][]uint8) (minX, minY, maxX, maxY, population int) {
+	minX, minY = -1, -1
+	maxX, maxY = -1, -1
+	for y, row := range cells {
+		for x, state := range row {
+			if state == 0 {
+				continue
+			}
+			if minY < 0 {
+				minY = y
+			}
+			if minX < 0 || x < minX {
+				minX = x
+			}
+			if x > maxX {
+				maxX = x
+			}
+			maxY = y
+			population++
+		}
+	}
+	if population == 0 {
+		return 0, 0, 0, 0, 0
+	}
+	return minX, minY, maxX, maxY, population
+}
+
+// encodeBox appends a compact copy of the cells in a box to buf: its width
+// and height, then the cells row by row as runs, each a length and the
+// state shared by the cells in it. Boxes are mostly runs of dead cells, so this is
+// far smaller than the cells themselves.
+func encodeBox(buf []byte, cells [][]uint8, minX, minY, maxX, maxY int) []byte {
+	buf = binary.AppendUvarint(buf, uint64(maxX-minX+1))
+	buf = binary.AppendUvarint(buf, uint64(maxY-minY+1))
+	run, state := 0, uint8(0)
+	for y := minY; y <= maxY; y++ {
+		for _, s := range cells[y][minX : maxX+1] {
+			if s != state && run > 0 {
+				buf = appendRun(buf, run, state)
+				run = 0
+			}
+			run++
+			state = s
+		}
+	}
+	return appendRun(buf, run, state)
+}
+
+// appendRun appends a run of cells in the same state to an encoded box.
+func appendRun(buf []byte, run int, state uint8) []byte {
+	buf = binary.AppendUvarint(buf, uint64(run))
+	return append(buf, state)
+}
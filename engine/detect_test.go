@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"image/color"
+	"math/rand/v2"
+	"testing"
+)
+
+// boardWith returns an empty board with a pattern drawn in rows of '.' for
+// dead cells, '#' for cells in state 1 and digits for other states, its
+// top-left corner at (x, y).
+func boardWith(width, height, x, y int, rows ...string) [][]uint8 {
+	cells := makeGrid[uint8](width, height)
+	for dy, row := range rows {
+		for dx, ch := range row {
+			switch {
+			case ch == '#':
+				cells[y+dy][x+dx] = 1
+			case ch >= '0' && ch <= '9':
+				cells[y+dy][x+dx] = uint8(ch - '0')
+			}
+		}
+	}
+	return cells
+}
+
+// blankColors returns a zeroed color grid the size of the board.
+func blankColors(cells [][]uint8) [][]color.RGBA {
+	return makeGrid[color.RGBA](len(cells[0]), len(cells))
+}
+
+// runDetection steps a universe under the rule from the given board and returns what was
+// detected after each generation.
+func runDetection(rule Rule, cells [][]uint8, generations int) []Detection {
+	u := NewUniverse(len(cells[0]), len(cells))
+	u.SetRule(rule)
+	u.Load(cells, blankColors(cells))
+	detections := make([]Detection, generations)
+	for gen := range detections {
+		u.Step()
+		detections[gen] = u.Detection()
+	}
+	return detections
+}
+
+var (
+	glider = []string{".#.", "..#", "###"}
+	lwss   = []string{"####.", "#...#", "#....", ".#..#"} // Moving left
+)
+
+func TestDetection(t *testing.T) {
+	tests := []struct {
+		name        string
+		rule        string
+		cells       [][]uint8
+		generations int
+		want        Detection
+	}{
+		{"glider", "life", boardWith(32, 32, 4, 4, glider...), 12, Detection{Kind: Spaceship, Period: 4, DX: 1, DY: 1, Generation: 4}},
+		{"lightweight spaceship", "life", boardWith(32, 32, 20, 14, lwss...), 12, Detection{Kind: Spaceship, Period: 4, DX: -2, Generation: 4}},
+		{"blinker", "life", boardWith(32, 32, 10, 10, "###"), 6, Detection{Kind: Oscillator, Period: 2, Generation: 2}},
+		{"block", "life", boardWith(32, 32, 10, 10, "##", "##"), 3, Detection{Kind: StillLife, Generation: 1}},
+		{"lone cell", "life", boardWith(32, 32, 10, 10, "#"), 3, Detection{Kind: Extinct, Generation: 1}},
+		{"brian's brain dies out", "brians-brain", boardWith(32, 32, 10, 10, "#2"), 3, Detection{Kind: Extinct, Generation: 2}},
+		{"brian's brain spaceship", "brians-brain", boardWith(32, 32, 10, 10, "22", "##"), 12, Detection{Kind: Spaceship, Period: 1, DY: 1, Generation: 1}},
+		{"billiard ball", "bbm", boardWith(32, 32, 10, 10, "#"), 12, Detection{Kind: Spaceship, Period: 2, DX: 2, DY: 2, Generation: 2}},
+		{"sand at rest", "sand", boardWith(32, 32, 10, 10, "11", "22"), 6, Detection{Kind: StillLife, Generation: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			detections := runDetection(rule, tt.cells, tt.generations)
+			if got := detections[len(detections)-1]; got != tt.want {
+				t.Errorf("detected %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectionAcrossEdges(t *testing.T) {
+	// Spaceships keep being reported as what they are while they wrap
+	// around the edges of a small board, many times over
+	tests := []struct {
+		name  string
+		cells [][]uint8
+		want  Detection
+	}{
+		{"glider", boardWith(16, 12, 4, 4, glider...), Detection{Kind: Spaceship, Period: 4, DX: 1, DY: 1, Generation: 4}},
+		{"lightweight spaceship", boardWith(16, 12, 6, 4, lwss...), Detection{Kind: Spaceship, Period: 4, DX: -2, Generation: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for gen, got := range runDetection(Life, tt.cells, 200) {
+				if gen+1 >= tt.want.Generation && got != tt.want {
+					t.Fatalf("generation %d: detected %+v, want %+v", gen+1, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectorCollision(t *testing.T) {
+	blinker := [2][][]uint8{boardWith(8, 8, 2, 3, "###"), boardWith(8, 8, 3, 2, "#", "#", "#")}
+	d := NewDetector(DefaultMaxPeriod)
+	d.Observe(blinker[0], 0)
+
+	// Pretend the second phase hashes like the first
+	minX, minY, maxX, maxY, _ := boundingBox(blinker[1])
+	hash := hashBytes(encodeBox(nil, blinker[1], minX, minY, maxX, maxY))
+	for _, past := range d.seen {
+		d.seen[hash] = past
+	}
+
+	if got := d.Observe(blinker[1], 1); got.Kind != Evolving {
+		t.Errorf("hash collision detected as %+v", got)
+	}
+	if got := d.Observe(blinker[0], 2); got.Kind != Oscillator || got.Period != 2 {
+		t.Errorf("blinker detected as %+v", got)
+	}
+}
+
+func TestDetectorMemoryBound(t *testing.T) {
+	d := NewDetector(DefaultMaxPeriod)
+	d.maxBytes = 4096
+	rng := rand.New(rand.NewPCG(1, 1))
+	for gen := 0; gen < 100; gen++ {
+		cells := boardWith(64, 64, 0, 0)
+		for y := range cells {
+			for x := range cells[y] {
+				cells[y][x] = uint8(rng.IntN(2))
+			}
+		}
+		d.Observe(cells, gen)
+		if d.bytes > d.maxBytes && len(d.order) > 1 {
+			t.Fatalf("generation %d: %d bytes of shapes kept, more than %d", gen, d.bytes, d.maxBytes)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package engine
+
+import (
+	"image/color"
+	"math/rand/v2"
+	"slices"
+)
+
+// CellChange sets a single cell as part of a manual edit.
+type CellChange struct {
+	X, Y  int
+	State uint8      // 0 for dead, 1 for alive, higher for the other states of multi-state rules
+	Color color.RGBA // Color given to the cell if it is set alive
+}
+
+// edit is one undoable step: the cells it changed at a given generation.
+type edit struct {
+	generation int
+	flips      []cellFlip
+}
+
+// Apply sets the given cells as a single undoable edit of the current
+// generation. Coordinates wrap around the edges of the board, and states the
+// rule does not have are ignored. Any generations recorded after the current
+// one are discarded.
+func (u *Universe) Apply(changes []CellChange) {
+	recolorer, _ := u.scheme.(Recolorer)
+	masks := make(map[int32]uint8)
+	for _, c := range changes {
+		if int(c.State) >= u.rule.States() {
+			continue
+		}
+		x := ((c.X % u.width) + u.width) % u.width
+		y := ((c.Y % u.height) + u.height) % u.height
+		if c.State != 0 {
+			u.colors[y][x] = c.Color
+			if recolorer != nil {
+				u.colors[y][x] = recolorer.Recolor(c.Color)
+			}
+		}
+		if u.cells[y][x] != c.State {
+			i := int32(y*u.width + x)
+			masks[i] ^= u.cells[y][x] ^ c.State
+			u.cells[y][x] = c.State
+		}
+	}
+
+	var flips []cellFlip
+	for i, mask := range masks {
+		if mask != 0 {
+			flips = append(flips, cellFlip{index: i, mask: mask})
+		}
+	}
+	if len(flips) == 0 {
+		return
+	}
+
+	u.history.Truncate(u.generation)
+	u.history.Amend(u.generation, flips)
+	u.markChanged(flips)
+	u.undo = append(u.undo, edit{generation: u.generation, flips: flips})
+	u.redo = nil
+	u.refresh()
+}
+
+// Extend sets the given cells as part of the latest edit, so that a brush
+// stroke is undone in one step. If the universe has moved on or anything was
+// undone since that edit, it starts a new one like Apply.
+func (u *Universe) Extend(changes []CellChange) {
+	last := len(u.undo) - 1
+	canMerge := last >= 0 && u.undo[last].generation == u.generation && len(u.redo) == 0
+	u.Apply(changes)
+	if !canMerge || len(u.undo) != last+2 {
+		return
+	}
+	previous := slices.Clone(u.undo[last].flips)
+	slices.SortFunc(previous, compareFlips)
+	u.undo[last].flips = combineFlips(previous, u.undo[last+1].flips)
+	u.undo = u.undo[:last+1]
+}
+
+// Undo reverts the most recent edit, rewinding to the generation it was made
+// in first. It returns false if there is nothing left that can be undone.
+func (u *Universe) Undo() bool {
+	return u.reapply(&u.undo, &u.redo)
+}
+
+// Redo reapplies the most recently undone edit. It returns false if there is
+// nothing to redo.
+func (u *Universe) Redo() bool {
+	return u.reapply(&u.redo, &u.undo)
+}
+
+// reapply pops an edit from one stack, toggles its cells again and pushes it
+// onto the other stack. Edits whose generation has left the history are dropped.
+func (u *Universe) reapply(from, to *[]edit) bool {
+	for len(*from) > 0 {
+		e := (*from)[len(*from)-1]
+		*from = (*from)[:len(*from)-1]
+		if !u.history.Contains(e.generation) {
+			continue
+		}
+
+		u.Seek(e.generation)
+		u.history.flip(u.cells, e.flips)
+		u.history.Truncate(e.generation)
+		u.history.Amend(e.generation, e.flips)
+		u.markChanged(e.flips)
+		*to = append(*to, e)
+		u.refresh()
+		return true
+	}
+	return false
+}
+
+// History returns the recorded generations the universe can be rewound to.
+func (u *Universe) History() *History {
+	return u.history
+}
+
+// StepBack rewinds the universe by one generation. It returns false if the
+// previous generation is no longer in the history.
+func (u *Universe) StepBack() bool {
+	return u.Seek(u.generation - 1)
+}
+
+// Seek rewinds or replays the universe to the given recorded generation.
+// Cell ages and activity cannot be recovered, so they restart from zero.
+// It returns false if the generation is not in the history.
+func (u *Universe) Seek(generation int) bool {
+	if !u.history.Contains(generation) {
+		return false
+	}
+	if generation == u.generation {
+		return true
+	}
+
+	u.history.Seek(u.cells, u.generation, generation)
+	u.generation = generation
+	u.clearActivity()
+	u.refresh()
+	return true
+}
+
+// replay moves forward to an already recorded generation without recomputing it.
+func (u *Universe) replay(generation int) {
+	delta := u.history.Delta(generation)
+	u.history.flip(u.cells, delta)
+	u.generation = generation
+	u.markChanged(delta)
+	s := u.census()
+	for _, f := range delta {
+		x, y := int(f.index)%u.width, int(f.index)/u.width
+		switch {
+		case u.cells[y][x] == f.mask:
+			// The cell was dead before
+			s.Births++
+			u.colors[y][x] = u.scheme.Birth(nil, u.rng)
+		case u.cells[y][x] == 0:
+			s.Deaths++
+		}
+	}
+	u.detector.Observe(u.cells, u.generation)
+
+	u.stats.Truncate(u.generation - 1)
+	u.stats.Add(s)
+	u.tiles.invalidate(u.width, u.height, u.rule.Neighborhood().Radius())
+}
+
+// refresh restarts detection and replaces the current generation's
+// statistics after the board was changed out of sequence.
+func (u *Universe) refresh() {
+	u.detector.Reset()
+	u.detector.Observe(u.cells, u.generation)
+	u.stats.Truncate(u.generation - 1)
+	u.stats.Add(u.census())
+	u.tiles.invalidate(u.width, u.height, u.rule.Neighborhood().Radius())
+}
+
+// randomColor generates a random RGB color with full opacity.
+func randomColor() color.RGBA {
+	return color.RGBA{
+		R: uint8(rand.IntN(256)),
+		G: uint8(rand.IntN(256)),
+		B: uint8(rand.IntN(256)),
+		A: 255,
+	}
+}
+
+// randomColorFrom generates a random RGB color with full opacity from rng,
+// using a single draw.
+func randomColorFrom(rng *rand.Rand) color.RGBA {
+	v := rng.Uint32()
+	return color.RGBA{R: uint8(v), G: uint8(v >> 8), B: uint8(v >> 16), A: 255}
+}
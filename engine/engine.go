@@ -1,40 +1,88 @@
 package engine
 
 import (
+	"errors"
 	"fmt"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
-	"github.com/hajimehoshi/ebiten/v2/vector"
 	"github.com/jared-wallace/gol/patterns"
 	"image/color"
 	"log"
-	"math/rand"
+	"strings"
 	"sync"
 	"time"
 )
 
 // Game implements the ebiten.Game interface.
 type Game struct {
-	width, height    int
-	cells            [][]bool
-	colors           [][]color.RGBA
-	nextCells        [][]bool
+	width, height    int // Size of the board
+	gridWidth        int // Cells that fit the window; the board loses a column or row under rules that need an even one
+	gridHeight       int
+	universe         *Universe
 	configIndex      int
-	generation       int
 	name             string
 	patternGenerator *patterns.PatternGenerator
+	rule             Rule // Rule for patterns whose files do not name one
+
+	// Problem with the loaded pattern, shown in the HUD
+	warning string
+
+	// Stop ticking once the universe dies out or starts repeating
+	stopOnSettle bool
+
+	// Start one-dimensional rules from a random line rather than a single cell
+	randomLine bool
+
+	// Fields for rewinding and editing
+	paused                    bool
+	scrubbing                 bool // Left mouse button went down on the timeline
+	painting                  bool // Left mouse button went down on the board
+	screenWidth, screenHeight int  // Last size passed to Layout
+
+	// Fields for selection and the clipboard
+	selection       *selection      // Selected rectangle, nil if nothing is selected
+	selecting       bool            // Left mouse button went down with Shift held
+	clipboard       *Clip           // Internal clipboard
+	systemClipboard systemClipboard // Background access to the system clipboard
+	pasting         *Clip           // Floating paste preview, nil when not pasting
+
+	// Fields for painting cells
+	paintState       uint8 // State clicks paint, picked with the number keys
+	strokeState      uint8 // State the current stroke paints, 0 if it erases
+	strokeX, strokeY int   // Last cell of the current stroke
+
+	// Fields for placing library patterns
+	pasteBase    *Clip // Oriented paste source before advancing to pastePhase
+	pastePhase   int   // Generations the paste preview is advanced by
+	libraryIndex int   // Index of the library pattern last picked for placing
+
+	// Fields for the statistics overlay
+	showGraph     bool
+	statusMessage string // Result of the last export, shown in the HUD
+
+	// Save the screen at the end of the next Draw
+	screenshotPending bool
+
+	// Fields for the HUD, help overlay and grid lines
+	hud          hudMode
+	showHelp     bool
+	showGrid     bool
+	gridCellSize int           // Smallest cell size grid lines are drawn at
+	gridImage    *ebiten.Image // Grid lines, drawn when the board or cells change size
+	gridKey      gridKey
+
+	// Fields for recording animations
+	recorder           *Recorder // Recording in progress, nil if not recording
+	recordAPNG         bool      // Save the recording as an animated PNG rather than a GIF
+	recordedGeneration int       // Generation last added to the recording
 
 	// Fields for cell size management
 	cellSize      int
 	cellSizeMutex sync.Mutex
 
-	// Fields for key state tracking
-	prevSpacePressed     bool
-	prevPlusPressed      bool
-	prevMinusPressed     bool
-	prevUpArrowPressed   bool
-	prevDownArrowPressed bool
-	prevEscPressed       bool
+	// Fields for input handling
+	keys             *keymap // Keys bound to actions
+	prevMousePressed bool
 
 	// Fields for tick speed management
 	tickSpeed       float64    // Ticks per second
@@ -46,24 +94,18 @@ type Game struct {
 
 // NewGame initializes a new Game instance.
 func NewGame(width, height int) *Game {
-	cells := make([][]bool, height)
-	colors := make([][]color.RGBA, height)
-	nextCells := make([][]bool, height)
-	for i := range cells {
-		cells[i] = make([]bool, width)
-		colors[i] = make([]color.RGBA, width)
-		nextCells[i] = make([]bool, width)
-	}
-
 	g := &Game{
 		width:            width,
 		height:           height,
-		cells:            cells,
-		colors:           colors,
-		nextCells:        nextCells,
-		generation:       0,
+		gridWidth:        width,
+		gridHeight:       height,
+		universe:         NewUniverse(width, height),
 		configIndex:      0,
 		patternGenerator: patterns.NewPatternGenerator(height, width),
+		rule:             Life,
+		keys:             defaultKeymap(),
+		paintState:       1,
+		gridCellSize:     defaultGridCellSize,
 		cellSize:         8, // Default cell size
 
 		// Initialize tick speed fields
@@ -73,11 +115,7 @@ func NewGame(width, height int) *Game {
 		lastUpdateTime:  time.Now(),
 	}
 
-	var err error
-	g.cells, g.colors, g.name, err = g.patternGenerator.GetConfig(0)
-	if err != nil {
-		log.Fatal(err)
-	}
+	g.loadConfig(0)
 
 	return g
 }
@@ -86,25 +124,145 @@ func (g *Game) GetCellSize() int {
 	return g.cellSize
 }
 
-// countAliveNeighbors returns the number of alive neighbors for a cell at (x, y).
-func (g *Game) countAliveNeighbors(x, y int) int {
-	count := 0
-	for dy := -1; dy <= 1; dy++ {
-		for dx := -1; dx <= 1; dx++ {
-			if dx == 0 && dy == 0 {
-				continue // Skip the cell itself
-			}
+// Universe returns the universe simulated by the game.
+func (g *Game) Universe() *Universe {
+	return g.universe
+}
 
-			// Wrap around the edges using modulo arithmetic
-			nx := (x + dx + g.width) % g.width
-			ny := (y + dy + g.height) % g.height
+// SetColorScheme selects the color scheme with the given name.
+func (g *Game) SetColorScheme(name string) error {
+	scheme, err := ColorSchemeByName(name)
+	if err != nil {
+		return err
+	}
+	g.universe.SetColorScheme(scheme)
+	return nil
+}
 
-			if g.cells[ny][nx] {
-				count++
-			}
+// SetRule selects the rule for patterns whose files do not name one, and
+// reloads the current pattern under it.
+func (g *Game) SetRule(rulestring string) error {
+	rule, err := ParseRule(rulestring)
+	if err != nil {
+		return err
+	}
+	g.rule = rule
+	g.loadConfig(g.configIndex)
+	return nil
+}
+
+// SetUpdateMode selects, by name, which cells are updated every generation:
+// "sync" for all of them, "fraction" for each with the given chance, or
+// "sequential" for random cells one after the other.
+func (g *Game) SetUpdateMode(name string, fraction float64) error {
+	mode, err := ParseUpdateMode(name)
+	if err != nil {
+		return err
+	}
+	if fraction <= 0 || fraction > 1 {
+		return fmt.Errorf("update fraction must be above 0 and at most 1")
+	}
+	g.universe.SetUpdateMode(mode, fraction)
+	return nil
+}
+
+// SetRandomLine makes one-dimensional rules start from a line of random
+// cells rather than from a single cell, and reloads the board.
+func (g *Game) SetRandomLine(random bool) {
+	g.randomLine = random
+	g.loadConfig(g.configIndex)
+}
+
+// SetStopOnSettle makes the game stop ticking as soon as the universe dies
+// out, becomes a still life, oscillates or starts translating.
+func (g *Game) SetStopOnSettle(stop bool) {
+	g.stopOnSettle = stop
+}
+
+// loadConfig loads the pattern at idx into the universe, under the rule
+// named by the pattern file if there is one. A pattern that is larger than
+// the grid zooms out until it fits; if even the smallest cell size is not
+// enough, it is cropped and a warning is shown in the HUD. One-dimensional
+// rules ignore the pattern and start from a seed line instead.
+func (g *Game) loadConfig(idx int) {
+	if rule, ok := g.rule.(*WolframRule); ok {
+		g.loadLine(rule)
+		return
+	}
+	rule := g.patternRule(idx)
+	g.fitBoard(rule)
+	cells, colors, name, err := g.patternGenerator.GetConfig(idx)
+	g.warning = ""
+	var tooLarge *patterns.PatternTooLargeError
+	if errors.As(err, &tooLarge) {
+		if !g.zoomToFit(tooLarge.Width, tooLarge.Height) {
+			log.Printf("Warning: %v", err)
+			g.warning = fmt.Sprintf("%s is %dx%d, larger than the %dx%d grid; cropped",
+				name, tooLarge.Width, tooLarge.Height, tooLarge.BoardWidth, tooLarge.BoardHeight)
 		}
+	} else if err != nil {
+		log.Fatal(err)
+	}
+	g.universe.Load(cells, colors)
+	g.name = name
+}
+
+// fitBoard sizes the board to the grid that fits the window, as far as the
+// rule allows, and sets the rule.
+func (g *Game) fitBoard(rule Rule) {
+	g.universe.SetRule(rule)
+	width, height := BoardSize(rule, g.gridWidth, g.gridHeight)
+	if width == g.width && height == g.height {
+		return
+	}
+	g.universe.Resize(width, height)
+	g.width, g.height = width, height
+	g.patternGenerator.SetHW(height, width)
+}
+
+// loadLine starts a one-dimensional rule from a single cell or a random
+// line, as selected by randomLine.
+func (g *Game) loadLine(rule *WolframRule) {
+	g.warning = ""
+	g.fitBoard(rule)
+	g.universe.Load(rule.Seed(g.width, g.height, g.randomLine), makeGrid[color.RGBA](g.width, g.height))
+	g.name = "single cell"
+	if g.randomLine {
+		g.name = "random line"
+	}
+}
+
+// patternRule returns the rule named by the file of the pattern at idx, or
+// the game's rule if it names none.
+func (g *Game) patternRule(idx int) Rule {
+	p, err := g.patternGenerator.GetPattern(idx)
+	if err != nil || p.Rule == "" {
+		return g.rule
+	}
+	rule, err := ParseRule(p.Rule)
+	if err != nil {
+		log.Printf("Warning: pattern '%s': %v", p.Name, err)
+		return g.rule
+	}
+	return rule
+}
+
+// zoomToFit shrinks the cell size so that a pattern of the given size fits
+// the window. Layout then grows the grid and reloads the pattern. It returns
+// false if no cell size makes the pattern fit.
+func (g *Game) zoomToFit(width, height int) bool {
+	if g.screenWidth == 0 || g.screenHeight == 0 || width == 0 || height == 0 {
+		return false
 	}
-	return count
+	g.cellSizeMutex.Lock()
+	defer g.cellSizeMutex.Unlock()
+
+	cellSize := min(g.screenWidth/width, g.screenHeight/height)
+	if cellSize < 1 || cellSize >= g.cellSize {
+		return false
+	}
+	g.cellSize = cellSize
+	return true
 }
 
 // Update is called every frame.
@@ -117,28 +275,27 @@ func (g *Game) Update() error {
 
 	// Determine if it's time to perform a tick
 	for g.tickAccumulator >= g.tickInterval {
-		// Perform a game tick
-		g.performTick()
+		// Perform a game tick unless paused, or the universe has settled and we were asked to stop
+		if !g.paused && (!g.stopOnSettle || !g.universe.Detection().Settled()) {
+			g.universe.Step()
+		}
 		g.tickAccumulator -= g.tickInterval
 	}
 	g.tickSpeedMutex.Unlock()
 
-	// Handle input: spacebar to switch configurations
-	var err error
-	currentSpacePressed := ebiten.IsKeyPressed(ebiten.KeySpace)
-	if currentSpacePressed && !g.prevSpacePressed {
+	g.keys.poll()
+
+	// Handle input: next pattern, or seeds of one-dimensional rules
+	if _, ok := g.rule.(*WolframRule); ok && g.keys.pressed(actionNextPattern) {
+		g.randomLine = !g.randomLine
+		g.loadConfig(g.configIndex)
+	} else if g.keys.pressed(actionNextPattern) {
 		g.configIndex = (g.configIndex + 1) % g.patternGenerator.GetPatternCount()
-		g.cells, g.colors, g.name, err = g.patternGenerator.GetConfig(g.configIndex)
-		if err != nil {
-			log.Fatal(err)
-		}
-		g.generation = 0
+		g.loadConfig(g.configIndex)
 	}
-	g.prevSpacePressed = currentSpacePressed
 
-	// Handle input: '+' to increase cell size
-	currentPlusPressed := ebiten.IsKeyPressed(ebiten.KeyEqual) || ebiten.IsKeyPressed(ebiten.KeyKPAdd)
-	if currentPlusPressed && !g.prevPlusPressed {
+	// Handle input: increase cell size
+	if g.keys.pressed(actionZoomIn) {
 		g.cellSizeMutex.Lock()
 		if g.cellSize < 20 { // Maximum cell size limit
 			g.cellSize++
@@ -146,11 +303,9 @@ func (g *Game) Update() error {
 		}
 		g.cellSizeMutex.Unlock()
 	}
-	g.prevPlusPressed = currentPlusPressed
 
-	// Handle input: '-' to decrease cell size
-	currentMinusPressed := ebiten.IsKeyPressed(ebiten.KeyMinus) || ebiten.IsKeyPressed(ebiten.KeyKPSubtract)
-	if currentMinusPressed && !g.prevMinusPressed {
+	// Handle input: decrease cell size
+	if g.keys.pressed(actionZoomOut) {
 		g.cellSizeMutex.Lock()
 		if g.cellSize > 1 { // Minimum cell size limit
 			g.cellSize--
@@ -158,11 +313,57 @@ func (g *Game) Update() error {
 		}
 		g.cellSizeMutex.Unlock()
 	}
-	g.prevMinusPressed = currentMinusPressed
 
-	currentEscPressed := ebiten.IsKeyPressed(ebiten.KeyEscape)
-	if currentEscPressed && !g.prevEscPressed {
-		return ebiten.Termination
+	// Handle input: toggle the population graph
+	if g.keys.pressed(actionToggleGraph) {
+		g.showGraph = !g.showGraph
+	}
+
+	// Handle input: cycle through the color schemes
+	if g.keys.pressed(actionNextColors) {
+		schemes := ColorSchemes()
+		next := 0
+		for i, scheme := range schemes {
+			if scheme.Name() == g.universe.ColorScheme().Name() {
+				next = (i + 1) % len(schemes)
+			}
+		}
+		g.universe.SetColorScheme(schemes[next])
+	}
+
+	// Handle input: export statistics
+	if g.keys.pressed(actionExportStats) {
+		base, err := g.exportStats()
+		if err != nil {
+			log.Printf("Failed to export statistics: %v", err)
+			g.statusMessage = "Export failed"
+		} else {
+			g.statusMessage = fmt.Sprintf("Exported %s.csv/.json", base)
+		}
+	}
+
+	// Handle rewinding, editing and selection input
+	g.handleHistoryInput()
+	g.handleSelectionInput()
+	g.handlePaletteInput()
+	g.handleMouseInput()
+	g.handleScreenshotInput()
+	g.handleRecordingInput()
+	g.handleHUDInput()
+	g.recordFrame()
+
+	// Handle input: back closes the help, then cancels pasting, then clears the selection, then exits
+	if g.keys.pressed(actionBack) {
+		switch {
+		case g.showHelp:
+			g.showHelp = false
+		case g.pasting != nil:
+			g.pasting = nil
+		case g.selection != nil:
+			g.selection = nil
+		default:
+			return ebiten.Termination
+		}
 	}
 
 	// Handle tick speed input
@@ -171,65 +372,79 @@ func (g *Game) Update() error {
 	return nil
 }
 
-// performTick contains the logic to update the game state
-func (g *Game) performTick() {
-	// Create a wait group for concurrency
-	var wg sync.WaitGroup
-	numWorkers := 8
-	rowsPerWorker := g.height / numWorkers
-
-	for w := 0; w < numWorkers; w++ {
-		startY := w * rowsPerWorker
-		endY := startY + rowsPerWorker
-		if w == numWorkers-1 {
-			endY = g.height
-		}
-		wg.Add(1)
-		go func(startY, endY int) {
-			defer wg.Done()
-			for y := startY; y < endY; y++ {
-				for x := 0; x < g.width; x++ {
-					aliveNeighbors := g.countAliveNeighbors(x, y)
-					if g.cells[y][x] {
-						// Cell is alive
-						if aliveNeighbors < 2 || aliveNeighbors > 3 {
-							g.nextCells[y][x] = false
-						} else {
-							g.nextCells[y][x] = true
-						}
-					} else {
-						// Cell is dead
-						if aliveNeighbors == 3 {
-							g.nextCells[y][x] = true
-							// Assign a color to the new cell
-							g.colors[y][x] = color.RGBA{
-								R: uint8(rand.Intn(256)),
-								G: uint8(rand.Intn(256)),
-								B: uint8(rand.Intn(256)),
-								A: 255,
-							}
-						} else {
-							g.nextCells[y][x] = false
-						}
-					}
-				}
-			}
-		}(startY, endY)
+// handleHistoryInput manages pausing, stepping through generations, undo and redo.
+func (g *Game) handleHistoryInput() {
+	// Handle input: pause and resume
+	if g.keys.pressed(actionPause) {
+		g.paused = !g.paused
 	}
 
-	wg.Wait()
+	// Handle input: step back or forward one generation
+	if g.keys.pressed(actionStepBack) {
+		g.paused = true
+		g.universe.StepBack()
+	}
+	if g.keys.pressed(actionStepForward) {
+		g.paused = true
+		g.universe.Step()
+	}
 
-	// Swap cells and nextCells
-	g.cells, g.nextCells = g.nextCells, g.cells
-	// Increment generation
-	g.generation++
+	// Handle input: undo and redo the last edit
+	if g.keys.pressed(actionUndo) {
+		g.paused = true
+		g.universe.Undo()
+	}
+	if g.keys.pressed(actionRedo) {
+		g.paused = true
+		g.universe.Redo()
+	}
+}
+
+// handleMouseInput manages the left mouse button: it stamps the paste
+// preview, scrubs the timeline while paused, drags out a selection with
+// Shift held, and otherwise paints cells along the cursor's path.
+func (g *Game) handleMouseInput() {
+	mx, my := ebiten.CursorPosition()
+	currentMousePressed := ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft)
+	if currentMousePressed && !g.prevMousePressed {
+		_, onTimeline := g.timelineGeneration(mx, my, g.screenWidth, g.screenHeight)
+		shiftPressed := ebiten.IsKeyPressed(ebiten.KeyShift)
+		g.scrubbing = g.paused && onTimeline
+		g.selecting = !g.scrubbing && g.pasting == nil && shiftPressed
+		switch {
+		case g.scrubbing:
+		case g.pasting != nil:
+			g.stampPaste(mx, my)
+		case g.selecting:
+			x, y := g.cellAt(mx, my)
+			g.selection = &selection{anchorX: x, anchorY: y, cornerX: x, cornerY: y}
+		default:
+			g.beginStroke(mx, my)
+		}
+	}
+	if currentMousePressed && g.painting {
+		g.continueStroke(mx, my)
+	}
+	if currentMousePressed && g.scrubbing {
+		if generation, ok := g.timelineGeneration(mx, my, g.screenWidth, g.screenHeight); ok {
+			g.universe.Seek(generation)
+		}
+	}
+	if currentMousePressed && g.selecting {
+		g.selection.cornerX, g.selection.cornerY = g.cellAt(mx, my)
+	}
+	if !currentMousePressed {
+		g.scrubbing = false
+		g.selecting = false
+		g.painting = false
+	}
+	g.prevMousePressed = currentMousePressed
 }
 
 // handleTickSpeedInput manages user input to adjust tick speed
 func (g *Game) handleTickSpeedInput() {
-	// Handle input: Up arrow to increase tick speed
-	currentUpKeyPressed := ebiten.IsKeyPressed(ebiten.KeyArrowUp)
-	if currentUpKeyPressed && !g.prevUpArrowPressed {
+	// Handle input: increase tick speed
+	if g.keys.pressed(actionFaster) {
 		g.tickSpeedMutex.Lock()
 		g.tickSpeed += 1.0
 		if g.tickSpeed > 60.0 { // Maximum tick speed limit
@@ -238,11 +453,9 @@ func (g *Game) handleTickSpeedInput() {
 		g.tickInterval = 1.0 / g.tickSpeed
 		g.tickSpeedMutex.Unlock()
 	}
-	g.prevUpArrowPressed = currentUpKeyPressed
 
-	// Handle input: Down arrow to decrease tick speed
-	currentDownKeyPressed := ebiten.IsKeyPressed(ebiten.KeyArrowDown)
-	if currentDownKeyPressed && !g.prevDownArrowPressed {
+	// Handle input: decrease tick speed
+	if g.keys.pressed(actionSlower) {
 		g.tickSpeedMutex.Lock()
 		g.tickSpeed -= 1.0
 		if g.tickSpeed < 1.0 { // Minimum tick speed limit
@@ -251,7 +464,6 @@ func (g *Game) handleTickSpeedInput() {
 		g.tickInterval = 1.0 / g.tickSpeed
 		g.tickSpeedMutex.Unlock()
 	}
-	g.prevDownArrowPressed = currentDownKeyPressed
 }
 
 // Draw renders the current state to the screen.
@@ -260,14 +472,13 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	cellSize := g.cellSize
 	g.cellSizeMutex.Unlock()
 
+	hex := g.hexagonal()
 	for y := 0; y < g.height; y++ {
+		shift := g.rowShift(y, cellSize, hex)
 		for x := 0; x < g.width; x++ {
-			if g.cells[y][x] {
-				col := g.colors[y][x]
-				rectX := x * cellSize
-				rectY := y * cellSize
+			if col, ok := g.universe.CellColor(x, y); ok {
 				// Draw a filled rectangle for the cell
-				vector.DrawFilledRect(screen, float32(rectX), float32(rectY), float32(cellSize), float32(cellSize), col, true)
+				g.drawRun(screen, x, y, 1, cellSize, shift, col)
 			}
 		}
 	}
@@ -277,19 +488,58 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	tickSpeed := g.tickSpeed
 	g.tickSpeedMutex.Unlock()
 
-	info := fmt.Sprintf(
-		"FPS: %.2f\nConfig: %s\nCell Size: %d\nGeneration: %d\nTick Speed: %.1f TPS\nPress SPACE to change config\nPress '+'/'-' to adjust cell size\nUse Up/Down arrows to adjust tick speed\nUse Escape to exit",
-		ebiten.ActualFPS(),
-		g.name,
-		g.cellSize,
-		g.generation,
-		tickSpeed,
-	)
-	ebitenutil.DebugPrint(screen, info)
+	statusNote := ""
+	if g.stopOnSettle && g.universe.Detection().Settled() {
+		statusNote += " (stopped)"
+	}
+	if g.paused {
+		statusNote += " (paused)"
+	}
+
+	info := fmt.Sprintf("FPS: %.2f\nConfig: %s\nCell Size: %d\nTick Speed: %.1f TPS\n", ebiten.ActualFPS(), g.name, g.cellSize, tickSpeed)
+	info += strings.Join(g.universe.hudLines(statusNote), "\n")
+	info += g.hudHint()
+	if g.statusMessage != "" {
+		info += "\n" + g.statusMessage
+	}
+	if g.warning != "" {
+		info += "\nWARNING: " + g.warning
+	}
+	if g.recorder != nil {
+		info += fmt.Sprintf("\nRecording, %d frames", g.recorder.Len())
+	}
+	if g.pasting != nil {
+		info += fmt.Sprintf("\nPasting, phase %d", g.pastePhase)
+	}
+	if g.universe.Rule().States() > 2 {
+		info += fmt.Sprintf("\nPainting %s, 1-%d to change", g.universe.StateName(g.paintState), min(g.universe.Rule().States()-1, maxPaintState))
+	}
+	g.drawGrid(screen, cellSize, hex)
+	g.drawSelection(screen, cellSize, hex)
+	if g.hud == hudFull {
+		ebitenutil.DebugPrint(screen, info)
+	}
+	g.drawPalette(screen)
+
+	if g.showGraph {
+		g.drawPopulationGraph(screen)
+	}
+	if g.paused {
+		g.drawTimeline(screen)
+	}
+	if g.hud != hudHidden {
+		g.drawStatusBar(screen, cellSize)
+	}
+	if g.showHelp {
+		g.drawHelp(screen)
+	}
+	g.captureScreen(screen)
 }
 
 // Layout takes the outside size (e.g., the window size) and returns the (logical) screen size.
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	g.screenWidth, g.screenHeight = outsideWidth, outsideHeight
+
 	g.cellSizeMutex.Lock()
 	cellSize := g.cellSize
 	g.cellSizeMutex.Unlock()
@@ -307,7 +557,7 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 	}
 
 	// If grid dimensions have changed, resize the grid
-	if gridWidth != g.width || gridHeight != g.height {
+	if gridWidth != g.gridWidth || gridHeight != g.gridHeight {
 		g.resizeGrid(gridWidth, gridHeight)
 	}
 
@@ -316,35 +566,7 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 
 // resizeGrid adjusts the grid size based on the new grid dimensions and current cell size.
 func (g *Game) resizeGrid(newWidth, newHeight int) {
-	// Create new slices with updated dimensions
-	newCells := make([][]bool, newHeight)
-	newColors := make([][]color.RGBA, newHeight)
-	newNextCells := make([][]bool, newHeight)
-	for y := 0; y < newHeight; y++ {
-		newCells[y] = make([]bool, newWidth)
-		newColors[y] = make([]color.RGBA, newWidth)
-		newNextCells[y] = make([]bool, newWidth)
-		// Copy existing data if within old bounds
-		if y < g.height {
-			for x := 0; x < newWidth && x < g.width; x++ {
-				newCells[y][x] = g.cells[y][x]
-				newColors[y][x] = g.colors[y][x]
-				newNextCells[y][x] = g.nextCells[y][x]
-			}
-		}
-	}
-
-	// Replace old slices with new ones
-	g.cells = newCells
-	g.colors = newColors
-	g.nextCells = newNextCells
-	g.width = newWidth
-	g.height = newHeight
-	g.patternGenerator.SetHW(newHeight, newWidth)
-	// Reload the current pattern after resizing
-	var err error
-	g.cells, g.colors, g.name, err = g.patternGenerator.GetConfig(g.configIndex)
-	if err != nil {
-		log.Fatal(err)
-	}
+	g.gridWidth, g.gridHeight = newWidth, newHeight
+	// Reload the current pattern, which sizes the board to the grid
+	g.loadConfig(g.configIndex)
 }
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Population graph geometry, in screen pixels
+const (
+	graphWidth   = 320
+	graphHeight  = 120
+	graphMargin  = 10
+	graphPadding = 4
+)
+
+var (
+	graphBackground = color.RGBA{R: 0, G: 0, B: 0, A: 180}
+	graphBorder     = color.RGBA{R: 128, G: 128, B: 128, A: 255}
+	graphLine       = color.RGBA{R: 80, G: 220, B: 120, A: 255}
+)
+
+// drawPopulationGraph draws the population over time in the bottom-right corner of the screen.
+func (g *Game) drawPopulationGraph(screen *ebiten.Image) {
+	bounds := screen.Bounds()
+	left := float32(bounds.Dx() - graphWidth - graphMargin)
+	top := float32(bounds.Dy() - graphHeight - graphMargin - timelineReserve)
+
+	vector.DrawFilledRect(screen, left, top, graphWidth, graphHeight, graphBackground, false)
+	vector.StrokeRect(screen, left, top, graphWidth, graphHeight, 1, graphBorder, false)
+
+	history := g.universe.Stats()
+	samples := history.Len()
+	if samples > graphWidth {
+		samples = graphWidth
+	}
+	if samples == 0 {
+		return
+	}
+	first := history.Len() - samples
+
+	// Scale the vertical axis to the largest population on screen
+	maxPopulation := 1
+	for i := first; i < history.Len(); i++ {
+		if p := history.At(i).Population; p > maxPopulation {
+			maxPopulation = p
+		}
+	}
+
+	plotWidth := float32(graphWidth - 2*graphPadding)
+	plotHeight := float32(graphHeight - 2*graphPadding)
+	point := func(i int) (float32, float32) {
+		x := left + graphPadding
+		if samples > 1 {
+			x += plotWidth * float32(i-first) / float32(samples-1)
+		}
+		y := top + graphPadding + plotHeight*(1-float32(history.At(i).Population)/float32(maxPopulation))
+		return x, y
+	}
+
+	prevX, prevY := point(first)
+	for i := first + 1; i < history.Len(); i++ {
+		x, y := point(i)
+		vector.StrokeLine(screen, prevX, prevY, x, y, 1, graphLine, true)
+		prevX, prevY = x, y
+	}
+
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Population (max %d)", maxPopulation), int(left)+graphPadding, int(top)+graphPadding)
+}
+
+// exportStats writes the recorded statistics to timestamped CSV and JSON
+// files in the working directory and returns the base name used.
+func (g *Game) exportStats() (string, error) {
+	base := fmt.Sprintf("stats-%s-%s", g.name, time.Now().Format("20060102-150405"))
+	history := g.universe.Stats()
+
+	csvFile, err := os.Create(base + ".csv")
+	if err != nil {
+		return "", fmt.Errorf("failed to create CSV file: %v", err)
+	}
+	defer csvFile.Close()
+	if err := history.WriteCSV(csvFile); err != nil {
+		return "", fmt.Errorf("failed to write CSV file: %v", err)
+	}
+
+	jsonFile, err := os.Create(base + ".json")
+	if err != nil {
+		return "", fmt.Errorf("failed to create JSON file: %v", err)
+	}
+	defer jsonFile.Close()
+	if err := history.WriteJSON(jsonFile); err != nil {
+		return "", fmt.Errorf("failed to write JSON file: %v", err)
+	}
+
+	return base, nil
+}
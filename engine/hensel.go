@@ -0,0 +1,177 @@
+package engine
+
+import (
+	"fmt"
+	"math/bits"
+	"slices"
+	"strings"
+)
+
+// henselTable says, for every configuration of the eight Moore neighbors,
+// whether it causes a birth (or a survival). A configuration is a 9-bit mask
+// of the 3x3 block around a cell in row-major order, so the center bit (16)
+// is always clear.
+type henselTable [512]bool
+
+// centerBit is the bit of a configuration that stands for the cell itself.
+const centerBit = 1 << 4
+
+// henselLetters names, for neighbor counts 0 to 4, the configurations that
+// are distinct up to rotation and reflection, in the order of the
+// representatives in henselRepresentatives. Counts 5 to 8 use the letters of
+// the complementary configurations.
+var henselLetters = [5]string{"", "ce", "ceaikn", "ceaiknjqry", "ceaiknjqrytwz"}
+
+// henselRepresentatives holds one configuration for every letter in henselLetters.
+var henselRepresentatives = [5][]int{
+	{0},
+	{1, 2},
+	{5, 10, 3, 40, 33, 68},
+	{69, 42, 11, 7, 98, 13, 14, 70, 41, 97},
+	{325, 170, 15, 45, 99, 71, 106, 102, 43, 101, 105, 78, 108},
+}
+
+// henselLetterOf holds the letter of every configuration, or 0 for counts 0 and 8.
+var henselLetterOf = func() [512]byte {
+	var letters [512]byte
+	for count := 1; count <= 4; count++ {
+		for i, rep := range henselRepresentatives[count] {
+			letter := henselLetters[count][i]
+			for _, c := range symmetries(rep) {
+				letters[c] = letter
+				if count < 4 {
+					// The complement has 8-count neighbors and the same letter
+					letters[^c&0x1ff&^centerBit] = letter
+				}
+			}
+		}
+	}
+	return letters
+}()
+
+// symmetries returns the configuration under every rotation and reflection
+// of the square.
+func symmetries(config int) []int {
+	transform := func(config int, f func(row, col int) (int, int)) int {
+		out := 0
+		for i := 0; i < 9; i++ {
+			if config&(1<<i) != 0 {
+				r, c := f(i/3, i%3)
+				out |= 1 << (r*3 + c)
+			}
+		}
+		return out
+	}
+	rotate := func(r, c int) (int, int) { return c, 2 - r }
+	mirror := func(r, c int) (int, int) { return r, 2 - c }
+
+	var all []int
+	for _, start := range []int{config, transform(config, mirror)} {
+		c := start
+		for i := 0; i < 4; i++ {
+			all = append(all, c)
+			c = transform(c, rotate)
+		}
+	}
+	return all
+}
+
+// parseHensel parses the neighbor conditions of a B or S part in isotropic
+// non-totalistic Hensel notation, such as "2-a" or "2-i34q". A count on its
+// own covers every configuration with that many neighbors, letters after it
+// restrict it to those configurations, and letters after a '-' exclude them.
+func parseHensel(spec string) (henselTable, error) {
+	var table henselTable
+	for i := 0; i < len(spec); {
+		d := spec[i]
+		if d < '0' || d > '8' {
+			return table, fmt.Errorf("invalid neighbor count '%c'", d)
+		}
+		count := int(d - '0')
+		i++
+
+		exclude := i < len(spec) && spec[i] == '-'
+		if exclude {
+			i++
+		}
+		letters := ""
+		for i < len(spec) && spec[i] >= 'a' && spec[i] <= 'z' {
+			letters += string(spec[i])
+			i++
+		}
+		valid := henselLetters[min(count, 8-count)]
+		for _, l := range letters {
+			if !strings.ContainsRune(valid, l) {
+				return table, fmt.Errorf("'%c' is not a configuration of %d neighbors", l, count)
+			}
+		}
+		if exclude && letters == "" {
+			return table, fmt.Errorf("'-' after %d is not followed by letters", count)
+		}
+
+		for config := range table {
+			if config&centerBit != 0 || bits.OnesCount(uint(config)) != count {
+				continue
+			}
+			listed := strings.IndexByte(letters, henselLetterOf[config]) >= 0
+			if letters == "" || listed != exclude {
+				table[config] = true
+			}
+		}
+	}
+	return table, nil
+}
+
+// String returns the table in Hensel notation, using plain counts wherever
+// a count is covered completely, and whichever of the listed or the
+// excluded letters is shorter otherwise.
+func (t *henselTable) String() string {
+	var b strings.Builder
+	for count := 0; count <= 8; count++ {
+		var in, out []string
+		for config, set := range t {
+			if config&centerBit != 0 || bits.OnesCount(uint(config)) != count {
+				continue
+			}
+			letter := string(henselLetterOf[config])
+			if set && !slices.Contains(in, letter) {
+				in = append(in, letter)
+			} else if !set && !slices.Contains(out, letter) {
+				out = append(out, letter)
+			}
+		}
+		slices.Sort(in)
+		slices.Sort(out)
+		switch {
+		case len(in) == 0:
+		case len(out) == 0:
+			fmt.Fprintf(&b, "%d", count)
+		case len(out) < len(in):
+			fmt.Fprintf(&b, "%d-%s", count, strings.Join(out, ""))
+		default:
+			fmt.Fprintf(&b, "%d%s", count, strings.Join(in, ""))
+		}
+	}
+	return b.String()
+}
+
+// mooreConfig returns the configuration of the neighbors of (x, y) that are
+// in state 1, wrapping around the edges of the board.
+func mooreConfig(cells [][]uint8, x, y int) int {
+	height, width := len(cells), len(cells[0])
+	left, right := (x+width-1)%width, (x+1)%width
+	above, below := cells[(y+height-1)%height], cells[(y+1)%height]
+	row := cells[y]
+
+	config := 0
+	for i, s := range [9]uint8{
+		above[left], above[x], above[right],
+		row[left], 0, row[right],
+		below[left], below[x], below[right],
+	} {
+		if s == 1 {
+			config |= 1 << i
+		}
+	}
+	return config
+}
@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// hexagonal reports whether the board is drawn as a hexagonal grid, which
+// is the case whenever the rule uses the hexagonal neighborhood.
+func (g *Game) hexagonal() bool {
+	return g.universe.Rule().Neighborhood().Hexagonal()
+}
+
+// rowShift returns how far to the right row y is drawn, in pixels. On a
+// hexagonal grid every row is shifted half a cell further than the row below
+// it, so that each cell touches exactly its six neighbors: the two beside
+// it, the two above it and the two below it.
+func (g *Game) rowShift(y, cellSize int, hex bool) float32 {
+	if !hex {
+		return 0
+	}
+	return float32((g.height-1-y)*cellSize) / 2
+}
+
+// drawRun fills n cells of row y starting at column x. Rows shifted past the
+// right edge of the board wrap around to the left edge, just like the cells.
+func (g *Game) drawRun(screen *ebiten.Image, x, y, n, cellSize int, shift float32, col color.Color) {
+	boardWidth := float32(g.width * cellSize)
+	left := float32(math.Mod(float64(float32(x*cellSize)+shift), float64(boardWidth)))
+	top, width, size := float32(y*cellSize), float32(n*cellSize), float32(cellSize)
+
+	vector.DrawFilledRect(screen, left, top, min(width, boardWidth-left), size, col, true)
+	if left+width > boardWidth {
+		vector.DrawFilledRect(screen, 0, top, left+width-boardWidth, size, col, true)
+	}
+}
+
+// screenToCell converts a screen position to the cell drawn there, and
+// reports whether the position is on the board at all.
+func (g *Game) screenToCell(mx, my, cellSize int) (x, y int, ok bool) {
+	if mx < 0 || my < 0 || mx >= g.width*cellSize || my >= g.height*cellSize {
+		return 0, 0, false
+	}
+	y = my / cellSize
+	shift := g.rowShift(y, cellSize, g.hexagonal())
+	x = int(math.Floor(float64((float32(mx) - shift) / float32(cellSize))))
+	return wrap(x, g.width), y, true
+}
@@ -0,0 +1,243 @@
+package engine
+
+import (
+	"slices"
+	"unsafe"
+)
+
+// History defaults
+const (
+	DefaultHistoryCapacity = 10000     // Generations kept for rewinding
+	DefaultHistoryBytes    = 256 << 20 // Memory kept for rewinding on large, busy boards
+	DefaultKeyframeEvery   = 64        // Generations between full snapshots
+)
+
+// cellFlip records a change to one cell: its new state is the old one XOR mask.
+type cellFlip struct {
+	index int32 // y*width + x
+	mask  uint8
+}
+
+// compareFlips orders flips by cell index.
+func compareFlips(a, b cellFlip) int {
+	return int(a.index) - int(b.index)
+}
+
+// historyFrame records one generation. Every frame stores the cells that
+// changed since the previous generation; keyframes also store a packed copy
+// of the whole board so distant generations can be rebuilt quickly.
+type historyFrame struct {
+	keyframe []uint8    // Packed board, nil for delta-only frames
+	delta    []cellFlip // Cells that changed, sorted by index
+}
+
+// size returns the memory taken by the frame's snapshot and delta in bytes.
+func (f historyFrame) size() int {
+	return len(f.keyframe) + int(unsafe.Sizeof(cellFlip{}))*len(f.delta)
+}
+
+// History keeps a bounded record of past generations so a universe can be
+// rewound and replayed. Because deltas are XOR masks, the same delta takes
+// a board forward or backward one generation. Besides the number of
+// generations, the history is bounded by the memory its frames take.
+type History struct {
+	capacity      int
+	maxBytes      int
+	bytes         int // Memory taken by frames
+	keyframeEvery int
+	width, height int
+	first         int // Generation of frames[0], which is always a keyframe
+	frames        []historyFrame
+}
+
+// NewHistory creates a history that keeps roughly the latest capacity
+// generations, with a full snapshot every keyframeEvery generations. Fewer
+// generations are kept once their frames take more than DefaultHistoryBytes.
+func NewHistory(capacity, keyframeEvery int) *History {
+	return &History{capacity: capacity, maxBytes: DefaultHistoryBytes, keyframeEvery: keyframeEvery}
+}
+
+// Reset forgets all recorded generations and starts again from cells.
+func (h *History) Reset(cells [][]uint8, generation int) {
+	h.height = len(cells)
+	h.width = 0
+	if h.height > 0 {
+		h.width = len(cells[0])
+	}
+	h.first = generation
+	h.frames = []historyFrame{{keyframe: h.pack(cells)}}
+	h.bytes = h.frames[0].size()
+}
+
+// Oldest returns the earliest generation that can still be restored.
+func (h *History) Oldest() int {
+	return h.first
+}
+
+// Newest returns the latest recorded generation.
+func (h *History) Newest() int {
+	return h.first + len(h.frames) - 1
+}
+
+// Contains reports whether generation can be restored.
+func (h *History) Contains(generation int) bool {
+	return generation >= h.Oldest() && generation <= h.Newest()
+}
+
+// Record appends the generation reached by flipping the given cells of the
+// previous one. Any generations recorded after it are discarded first.
+func (h *History) Record(generation int, flips []cellFlip, cells [][]uint8) {
+	h.Truncate(generation - 1)
+	frame := historyFrame{delta: flips}
+	if generation%h.keyframeEvery == 0 {
+		frame.keyframe = h.pack(cells)
+	}
+	h.frames = append(h.frames, frame)
+	h.bytes += frame.size()
+
+	// Drop whole keyframe intervals from the front so the oldest frame stays a keyframe
+	for len(h.frames) > h.capacity || h.bytes > h.maxBytes {
+		drop := 1
+		for drop < len(h.frames) && h.frames[drop].keyframe == nil {
+			drop++
+		}
+		if drop == len(h.frames) {
+			break
+		}
+		for _, f := range h.frames[:drop] {
+			h.bytes -= f.size()
+		}
+		h.frames = append([]historyFrame(nil), h.frames[drop:]...)
+		h.first += drop
+	}
+}
+
+// Truncate discards every generation after the given one.
+func (h *History) Truncate(generation int) {
+	if keep := generation - h.first + 1; keep >= 1 && keep < len(h.frames) {
+		for _, f := range h.frames[keep:] {
+			h.bytes -= f.size()
+		}
+		h.frames = h.frames[:keep]
+	}
+}
+
+// Delta returns the cells that changed between generation-1 and generation.
+func (h *History) Delta(generation int) []cellFlip {
+	return h.frames[generation-h.first].delta
+}
+
+// Amend folds cell flips made by an edit into the given generation, so that
+// the recorded board matches the edited one.
+func (h *History) Amend(generation int, flips []cellFlip) {
+	frame := &h.frames[generation-h.first]
+	if frame.keyframe != nil {
+		for _, f := range flips {
+			frame.keyframe[f.index] ^= f.mask
+		}
+	}
+	if generation > h.first {
+		h.bytes -= frame.size()
+		frame.delta = combineFlips(frame.delta, flips)
+		h.bytes += frame.size()
+	}
+}
+
+// Seek turns cells, which currently hold generation from, into generation
+// to. It either walks the deltas from the current board or starts from the
+// nearest keyframe, whichever touches fewer frames.
+func (h *History) Seek(cells [][]uint8, from, to int) {
+	if from == to {
+		return
+	}
+
+	// Find the nearest keyframe to the target
+	key := -1
+	for i := to - h.first; i >= 0; i-- {
+		if h.frames[i].keyframe != nil {
+			key = i + h.first
+			break
+		}
+	}
+	for i := to - h.first + 1; i < len(h.frames); i++ {
+		if h.frames[i].keyframe != nil {
+			if key < 0 || i+h.first-to < to-key {
+				key = i + h.first
+			}
+			break
+		}
+	}
+
+	if key >= 0 && abs(to-key) < abs(to-from) {
+		h.unpack(h.frames[key-h.first].keyframe, cells)
+		from = key
+	}
+	for from < to {
+		from++
+		h.flip(cells, h.frames[from-h.first].delta)
+	}
+	for from > to {
+		h.flip(cells, h.frames[from-h.first].delta)
+		from--
+	}
+}
+
+// flip applies the given changes to cells.
+func (h *History) flip(cells [][]uint8, flips []cellFlip) {
+	for _, f := range flips {
+		x, y := int(f.index)%h.width, int(f.index)/h.width
+		cells[y][x] ^= f.mask
+	}
+}
+
+// pack copies the board into a single slice.
+func (h *History) pack(cells [][]uint8) []uint8 {
+	packed := make([]uint8, 0, h.width*h.height)
+	for _, row := range cells {
+		packed = append(packed, row...)
+	}
+	return packed
+}
+
+// unpack restores a board stored by pack.
+func (h *History) unpack(packed []uint8, cells [][]uint8) {
+	for y, row := range cells {
+		copy(row, packed[y*h.width:])
+	}
+}
+
+// combineFlips returns the sorted changes that a followed by b make. Changes
+// to the same cell are merged, and dropped if they cancel out.
+func combineFlips(a, b []cellFlip) []cellFlip {
+	sorted := slices.Clone(b)
+	slices.SortFunc(sorted, compareFlips)
+
+	result := make([]cellFlip, 0, len(a)+len(sorted))
+	i, j := 0, 0
+	for i < len(a) && j < len(sorted) {
+		switch {
+		case a[i].index < sorted[j].index:
+			result = append(result, a[i])
+			i++
+		case a[i].index > sorted[j].index:
+			result = append(result, sorted[j])
+			j++
+		default:
+			if mask := a[i].mask ^ sorted[j].mask; mask != 0 {
+				result = append(result, cellFlip{index: a[i].index, mask: mask})
+			}
+			i++
+			j++
+		}
+	}
+	result = append(result, a[i:]...)
+	return append(result, sorted[j:]...)
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
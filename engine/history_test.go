@@ -0,0 +1,229 @@
+package engine
+
+import "testing"
+
+// historyGenerations is how many generations the history tests record,
+// enough to span a few keyframes.
+const historyGenerations = 300
+
+// recordedSoup returns a universe that ran a Life soup for
+// historyGenerations-1 generations, and the board of every generation.
+func recordedSoup() (*Universe, [][][]uint8) {
+	u := NewUniverse(64, 48)
+	u.Load(soup(64, 48, 0.35, 1))
+	boards := [][][]uint8{snapshot(u)}
+	for gen := 1; gen < historyGenerations; gen++ {
+		u.Step()
+		boards = append(boards, snapshot(u))
+	}
+	return u, boards
+}
+
+// snapshot returns a copy of the board.
+func snapshot(u *Universe) [][]uint8 {
+	cells := makeGrid[uint8](u.width, u.height)
+	for y := range cells {
+		copy(cells[y], u.cells[y])
+	}
+	return cells
+}
+
+// assertBoard fails if the universe is not at the given generation with the
+// given board.
+func assertBoard(t *testing.T, u *Universe, generation int, want [][]uint8) {
+	t.Helper()
+	if u.Generation() != generation {
+		t.Fatalf("at generation %d, want %d", u.Generation(), generation)
+	}
+	for y := range want {
+		for x := range want[y] {
+			if u.cells[y][x] != want[y][x] {
+				t.Fatalf("generation %d: cell %d, %d is %d, want %d", generation, x, y, u.cells[y][x], want[y][x])
+			}
+		}
+	}
+}
+
+// nextBoard returns the generation after cells under the rule, computing
+// every cell of the board.
+func nextBoard(rule Rule, cells [][]uint8) [][]uint8 {
+	next := makeGrid[uint8](len(cells[0]), len(cells))
+	for y := range cells {
+		for x := range cells[y] {
+			next[y][x] = rule.Next(cells, x, y)
+		}
+	}
+	return next
+}
+
+func TestHistorySeek(t *testing.T) {
+	if DefaultKeyframeEvery != 64 {
+		t.Fatalf("the seeks below straddle keyframes every 64 generations, not %d", DefaultKeyframeEvery)
+	}
+	u, boards := recordedSoup()
+	tests := []struct {
+		name   string
+		seeks  []int // Generations sought one after the other, from the last one
+		replay int   // Generations then stepped forward
+	}{
+		{"start", []int{0}, 70},
+		{"first", []int{1}, 3},
+		{"before keyframe", []int{63}, 2},
+		{"keyframe", []int{64}, 1},
+		{"between keyframes", []int{137}, 64},
+		{"last", []int{299}, 0},
+		{"onto keyframe from before it", []int{63, 64}, 0},
+		{"off keyframe backwards", []int{64, 63}, 0},
+		{"back and forth", []int{137, 0, 299, 64, 63, 1}, 130},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !u.Seek(historyGenerations - 1) {
+				t.Fatal("cannot seek to the last generation")
+			}
+			for _, gen := range tt.seeks {
+				if !u.Seek(gen) {
+					t.Fatalf("cannot seek to generation %d", gen)
+				}
+				assertBoard(t, u, gen, boards[gen])
+			}
+			for gen := tt.seeks[len(tt.seeks)-1] + 1; gen <= tt.seeks[len(tt.seeks)-1]+tt.replay; gen++ {
+				u.Step()
+				assertBoard(t, u, gen, boards[gen])
+			}
+			if newest := u.History().Newest(); newest != historyGenerations-1 {
+				t.Errorf("history ends at %d after replaying, want %d", newest, historyGenerations-1)
+			}
+		})
+	}
+}
+
+func TestHistoryStepBack(t *testing.T) {
+	u, boards := recordedSoup()
+	for gen := historyGenerations - 2; gen >= 0; gen-- {
+		if !u.StepBack() {
+			t.Fatalf("cannot step back to generation %d", gen)
+		}
+		assertBoard(t, u, gen, boards[gen])
+	}
+	if u.StepBack() {
+		t.Error("stepped back past the first generation")
+	}
+}
+
+func TestHistoryEditAfterRewind(t *testing.T) {
+	u, boards := recordedSoup()
+	u.Seek(137)
+	u.Apply([]CellChange{{X: 10, Y: 10, State: 1}, {X: 11, Y: 10, State: 1}, {X: 12, Y: 10, State: 1}, {X: 10, Y: 11, State: 0}})
+	edited := snapshot(u)
+	if newest := u.History().Newest(); newest != 137 {
+		t.Fatalf("history ends at %d after editing generation 137", newest)
+	}
+
+	// The next generation is computed from the edited board rather than
+	// replayed
+	want := nextBoard(Life, edited)
+	u.Step()
+	assertBoard(t, u, 138, want)
+	if u.History().Newest() != 138 {
+		t.Errorf("history ends at %d, want 138", u.History().Newest())
+	}
+	u.StepBack()
+	assertBoard(t, u, 137, edited)
+	u.Step()
+	assertBoard(t, u, 138, want)
+
+	// Undoing goes back to the generation of the edit and restores the
+	// board as recorded, from which stepping recomputes the old future
+	if !u.Undo() {
+		t.Fatal("nothing to undo")
+	}
+	assertBoard(t, u, 137, boards[137])
+	for gen := 138; gen < historyGenerations; gen++ {
+		u.Step()
+		assertBoard(t, u, gen, boards[gen])
+	}
+
+	// Redoing after rewinding further back goes forward to the edit again
+	u.Seek(20)
+	if !u.Redo() {
+		t.Fatal("nothing to redo")
+	}
+	assertBoard(t, u, 137, edited)
+	u.Step()
+	assertBoard(t, u, 138, want)
+}
+
+func TestHistoryUndoAfterRewind(t *testing.T) {
+	u := NewUniverse(64, 48)
+	u.Load(soup(64, 48, 0.35, 2))
+	for gen := 1; gen <= 50; gen++ {
+		u.Step()
+	}
+	unedited := snapshot(u)
+	u.Apply([]CellChange{{X: 30, Y: 20, State: 1}, {X: 31, Y: 20, State: 1}, {X: 32, Y: 20, State: 1}})
+	boards := map[int][][]uint8{50: snapshot(u)}
+	for gen := 51; gen < historyGenerations; gen++ {
+		u.Step()
+		boards[gen] = snapshot(u)
+	}
+
+	// Undo rewinds to the edit from wherever the universe was rewound to
+	u.Seek(200)
+	if !u.Undo() {
+		t.Fatal("nothing to undo")
+	}
+	assertBoard(t, u, 50, unedited)
+	if newest := u.History().Newest(); newest != 50 {
+		t.Errorf("history ends at %d after undoing, want 50", newest)
+	}
+
+	// Redo restores the edit, and the same generations follow it again
+	if !u.Redo() {
+		t.Fatal("nothing to redo")
+	}
+	assertBoard(t, u, 50, boards[50])
+	for gen := 51; gen < historyGenerations; gen++ {
+		u.Step()
+		assertBoard(t, u, gen, boards[gen])
+	}
+}
+
+func TestHistoryMemoryBound(t *testing.T) {
+	h := NewHistory(DefaultHistoryCapacity, 8)
+	h.maxBytes = 16 << 10
+	cells, _ := soup(64, 64, 0.35, 3)
+	h.Reset(cells, 0)
+	for gen := 1; gen <= 200; gen++ {
+		var flips []cellFlip
+		for i := int32(gen % 61); i < 64*64; i += 61 {
+			flips = append(flips, cellFlip{index: i, mask: 1})
+		}
+		h.flip(cells, flips)
+		h.Record(gen, flips, cells)
+		if h.bytes > h.maxBytes {
+			t.Fatalf("generation %d: %d bytes kept, more than %d", gen, h.bytes, h.maxBytes)
+		}
+		if h.frames[0].keyframe == nil {
+			t.Fatalf("generation %d: oldest frame is not a keyframe", gen)
+		}
+	}
+	if h.Newest() != 200 || h.Oldest() == 0 {
+		t.Errorf("history spans generations %d to %d", h.Oldest(), h.Newest())
+	}
+
+	// The oldest generation kept can still be restored
+	want := make([][]uint8, len(cells))
+	for y := range cells {
+		want[y] = append([]uint8(nil), cells[y]...)
+	}
+	h.Seek(cells, 200, h.Oldest())
+	h.Seek(cells, h.Oldest(), 200)
+	for y := range want {
+		for x := range want[y] {
+			if cells[y][x] != want[y][x] {
+				t.Fatalf("cell %d, %d changed after seeking back and forth", x, y)
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package engine
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// HUD geometry, in screen pixels. The debug font is 6 by 16 pixels.
+const (
+	statusBarHeight = 16
+	helpPadding     = 12
+	debugCharWidth  = 6
+	debugLineHeight = 16
+)
+
+// defaultGridCellSize is the smallest cell size grid lines are drawn at,
+// unless set otherwise.
+const defaultGridCellSize = 6
+
+var (
+	statusBarBackground = color.RGBA{R: 0, G: 0, B: 0, A: 200}
+	helpBackground      = color.RGBA{R: 0, G: 0, B: 0, A: 220}
+	helpBorder          = color.RGBA{R: 128, G: 128, B: 128, A: 255}
+)
+
+// hudMode is how much of the HUD is shown, cycled through with Tab by
+// default.
+type hudMode int
+
+const (
+	hudFull      hudMode = iota // The information block and the status bar
+	hudStatusBar                // Just the status bar
+	hudHidden                   // Nothing but the board
+)
+
+// mouseBindings lists what the mouse buttons do, for the help overlay.
+var mouseBindings = [][2]string{
+	{"Click, drag", "Paint cells, or stamp the pattern being pasted"},
+	{"Shift+drag", "Select cells"},
+	{"Click timeline", "Seek to a generation, while paused"},
+}
+
+// helpBindings returns the keys bound to every action and what the mouse
+// does, for the help overlay. The paint actions share one line.
+func (g *Game) helpBindings() [][2]string {
+	var bindings [][2]string
+	for a := action(0); a < actionPaint1; a++ {
+		bindings = append(bindings, [2]string{g.keys.keys(a), actionSpecs[a].description})
+	}
+	paintKeys := g.keys.keys(actionPaint1) + " .. " + g.keys.keys(actionPaint1+maxPaintState-1)
+	bindings = append(bindings, [2]string{paintKeys, "Pick the state to paint, for rules with more states"})
+	return append(bindings, mouseBindings...)
+}
+
+// hudHint returns the line of the HUD that tells which keys show the help
+// and hide the HUD, as currently bound, leaving out unbound ones.
+func (g *Game) hudHint() string {
+	var hints []string
+	if keys := g.keys.keys(actionHelp); keys != "" {
+		hints = append(hints, fmt.Sprintf("Press %s for help", keys))
+	}
+	if keys := g.keys.keys(actionToggleHUD); keys != "" {
+		hints = append(hints, fmt.Sprintf("%s to hide the HUD", keys))
+	}
+	if len(hints) == 0 {
+		return ""
+	}
+	return "\n" + strings.Join(hints, ", ")
+}
+
+// handleHUDInput manages cycling through the HUD modes, Tab by default,
+// showing the help overlay, F1, and toggling grid lines, F2.
+func (g *Game) handleHUDInput() {
+	if g.keys.pressed(actionToggleHUD) {
+		g.hud = (g.hud + 1) % (hudHidden + 1)
+	}
+	if g.keys.pressed(actionHelp) {
+		g.showHelp = !g.showHelp
+	}
+	if g.keys.pressed(actionToggleGrid) {
+		g.showGrid = !g.showGrid
+	}
+}
+
+// SetGrid selects whether grid lines are drawn between cells, and the
+// smallest cell size they are drawn at.
+func (g *Game) SetGrid(show bool, minCellSize int) {
+	g.showGrid = show
+	g.gridCellSize = minCellSize
+}
+
+// drawGrid draws lines along the top and left edges of every cell, if grid
+// lines are on and cells are large enough. The lines are drawn once into an
+// image of their own, and again only when the board or cells change size.
+func (g *Game) drawGrid(screen *ebiten.Image, cellSize int, hex bool) {
+	if !g.showGrid || cellSize < max(g.gridCellSize, minGridCellSize) {
+		return
+	}
+	key := gridKey{width: g.width, height: g.height, cellSize: cellSize, hex: hex}
+	if g.gridImage == nil || g.gridKey != key {
+		if g.gridImage != nil {
+			g.gridImage.Deallocate()
+		}
+		g.gridImage = g.renderGrid(cellSize, hex)
+		g.gridKey = key
+	}
+	screen.DrawImage(g.gridImage, nil)
+}
+
+// gridKey is what the grid image depends on.
+type gridKey struct {
+	width, height, cellSize int
+	hex                     bool
+}
+
+// renderGrid draws the grid lines of the board into a new image. Under
+// hexagonal rules the lines between cells follow the shift of each row,
+// wrapping around the right edge like the cells do.
+func (g *Game) renderGrid(cellSize int, hex bool) *ebiten.Image {
+	boardWidth, boardHeight := g.width*cellSize, g.height*cellSize
+	img := ebiten.NewImage(boardWidth, boardHeight)
+	size := float32(cellSize)
+	for y := 0; y < g.height; y++ {
+		top := float32(y * cellSize)
+		vector.DrawFilledRect(img, 0, top, float32(boardWidth), 1, gridColor, false)
+		if !hex {
+			continue
+		}
+		shift := g.rowShift(y, cellSize, hex)
+		for x := 0; x < g.width; x++ {
+			left := float32(math.Mod(float64(float32(x*cellSize)+shift), float64(boardWidth)))
+			vector.DrawFilledRect(img, left, top, 1, size, gridColor, false)
+		}
+	}
+	if !hex {
+		for x := 0; x < g.width; x++ {
+			vector.DrawFilledRect(img, float32(x*cellSize), 0, 1, float32(boardHeight), gridColor, false)
+		}
+	}
+	return img
+}
+
+// drawStatusBar draws a bar along the bottom of the screen with the
+// generation, population and rule, and the cell under the cursor.
+func (g *Game) drawStatusBar(screen *ebiten.Image, cellSize int) {
+	bounds := screen.Bounds()
+	top := bounds.Dy() - statusBarHeight
+	vector.DrawFilledRect(screen, 0, float32(top), float32(bounds.Dx()), statusBarHeight, statusBarBackground, false)
+
+	status := fmt.Sprintf("Generation %d | Population %d | Rule %s", g.universe.Generation(), g.universe.Population(), g.universe.Rule())
+	mx, my := ebiten.CursorPosition()
+	if x, y, ok := g.screenToCell(mx, my, cellSize); ok {
+		status += fmt.Sprintf(" | Cell %d, %d", x, y)
+		if g.universe.Rule().States() > 2 {
+			status += ": " + g.universe.StateName(g.universe.State(x, y))
+		}
+	}
+	ebitenutil.DebugPrintAt(screen, status, 4, top)
+}
+
+// drawHelp draws the help overlay, listing every binding as currently
+// bound, in the middle of the screen.
+func (g *Game) drawHelp(screen *ebiten.Image) {
+	lines, width := g.helpLines()
+	bounds := screen.Bounds()
+	w := width*debugCharWidth + 2*helpPadding
+	h := len(lines)*debugLineHeight + 2*helpPadding
+	x, y := (bounds.Dx()-w)/2, (bounds.Dy()-h)/2
+	vector.DrawFilledRect(screen, float32(x), float32(y), float32(w), float32(h), helpBackground, false)
+	vector.StrokeRect(screen, float32(x), float32(y), float32(w), float32(h), 1, helpBorder, false)
+	ebitenutil.DebugPrintAt(screen, strings.Join(lines, "\n"), x+helpPadding, y+helpPadding)
+}
+
+// helpLines returns the lines of the help overlay, the keys of the bindings
+// in a column of their own, and the width of the longest line in characters.
+func (g *Game) helpLines() ([]string, int) {
+	bindings := g.helpBindings()
+	keyWidth, textWidth := 0, 0
+	for _, b := range bindings {
+		keyWidth = max(keyWidth, len(b[0]))
+		textWidth = max(textWidth, len(b[1]))
+	}
+	lines := []string{"Key bindings", ""}
+	for _, b := range bindings {
+		lines = append(lines, fmt.Sprintf("%-*s  %s", keyWidth, b[0], b[1]))
+	}
+	return lines, keyWidth + 2 + textWidth
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHelpBindings(t *testing.T) {
+	g := &Game{keys: defaultKeymap()}
+	descriptions := make(map[string]string)
+	for _, b := range g.helpBindings() {
+		if b[0] == "" || b[1] == "" {
+			t.Errorf("binding %q has no key or no description", b)
+		}
+		descriptions[b[1]] = b[0]
+	}
+	// Every action but painting has a line of its own, with its keys
+	for a := action(0); a < actionPaint1; a++ {
+		if got, want := descriptions[actionSpecs[a].description], strings.Join(actionSpecs[a].keys, " / "); got != want {
+			t.Errorf("%s listed with keys %q, want %q", actionSpecs[a].name, got, want)
+		}
+	}
+	if got := descriptions["Pick the state to paint, for rules with more states"]; got != "Digit1 .. Digit9" {
+		t.Errorf("paint keys listed as %q", got)
+	}
+
+	// Rebound keys are listed as bound
+	if err := g.keys.load(strings.NewReader(`{"bindings": {"help": ["H", "Shift+Slash"], "export-stats": []}}`)); err != nil {
+		t.Fatal(err)
+	}
+	descriptions = make(map[string]string)
+	for _, b := range g.helpBindings() {
+		descriptions[b[1]] = b[0]
+	}
+	if got := descriptions[actionSpecs[actionHelp].description]; got != "H / Shift+Slash" {
+		t.Errorf("help listed with keys %q after rebinding", got)
+	}
+	if got, ok := descriptions[actionSpecs[actionExportStats].description]; !ok || got != "" {
+		t.Errorf("unbound action listed with keys %q", got)
+	}
+}
+
+func TestHelpLines(t *testing.T) {
+	g := &Game{keys: defaultKeymap()}
+	bindings := g.helpBindings()
+	lines, width := g.helpLines()
+	if len(lines) != len(bindings)+2 {
+		t.Fatalf("%d lines for %d bindings", len(lines), len(bindings))
+	}
+	// Descriptions line up in one column
+	column := strings.Index(lines[2], bindings[0][1])
+	for i, b := range bindings {
+		line := lines[i+2]
+		if !strings.HasPrefix(line, b[0]) || strings.Index(line, b[1]) != column {
+			t.Errorf("line %q does not start with %q and %q at column %d", line, b[0], b[1], column)
+		}
+		if len(line) > width {
+			t.Errorf("line %q longer than the width %d", line, width)
+		}
+	}
+	// The overlay fits a 640x720 window
+	if w, h := width*debugCharWidth+2*helpPadding, len(lines)*debugLineHeight+2*helpPadding; w > 640 || h > 720 {
+		t.Errorf("help overlay is %dx%d, larger than 640x720", w, h)
+	}
+}
+
+func TestHUDHint(t *testing.T) {
+	tests := []struct {
+		name, file, want string
+	}{
+		{"default keys", `{}`, "\nPress F1 for help, Tab to hide the HUD"},
+		{"rebound keys", `{"bindings": {"help": ["H", "Shift+Slash"], "toggle-hud": ["Ctrl+H"]}}`, "\nPress H / Shift+Slash for help, Ctrl+H to hide the HUD"},
+		{"help unbound", `{"bindings": {"help": []}}`, "\nTab to hide the HUD"},
+		{"both unbound", `{"bindings": {"help": [], "toggle-hud": []}}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &Game{keys: defaultKeymap()}
+			if err := g.keys.load(strings.NewReader(tt.file)); err != nil {
+				t.Fatal(err)
+			}
+			if got := g.hudHint(); got != tt.want {
+				t.Errorf("hint %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
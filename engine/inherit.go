@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"image/color"
+	"math/rand/v2"
+)
+
+// InheritMajorityScheme gives a newborn the color shared by most of its
+// parents, or the color of a random parent if they all differ, so colors
+// spread along with the structures that carry them.
+type InheritMajorityScheme struct{}
+
+func (InheritMajorityScheme) Name() string { return "inherit-majority" }
+
+func (InheritMajorityScheme) Birth(parents []color.RGBA, rng *rand.Rand) color.RGBA {
+	if len(parents) == 0 {
+		return randomColorFrom(rng)
+	}
+	if c, ok := majority(parents); ok {
+		return c
+	}
+	return parents[rng.IntN(len(parents))]
+}
+
+func (InheritMajorityScheme) CellColor(c CellInfo) (color.RGBA, bool) {
+	return c.Color, c.Alive
+}
+
+// InheritAverageScheme gives a newborn the average color of its parents.
+type InheritAverageScheme struct{}
+
+func (InheritAverageScheme) Name() string { return "inherit-average" }
+
+func (InheritAverageScheme) Birth(parents []color.RGBA, rng *rand.Rand) color.RGBA {
+	if len(parents) == 0 {
+		return randomColorFrom(rng)
+	}
+	var r, g, b int
+	for _, p := range parents {
+		r += int(p.R)
+		g += int(p.G)
+		b += int(p.B)
+	}
+	n := len(parents)
+	return color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: 255}
+}
+
+func (InheritAverageScheme) CellColor(c CellInfo) (color.RGBA, bool) {
+	return c.Color, c.Alive
+}
+
+// PaletteScheme turns Life into a multi-color rule: every live cell has one
+// of a fixed set of colors, and a newborn takes the color held by most of its
+// parents. When the parents all differ and the palette has a color none of
+// them hold, the newborn takes that color instead (as in QuadLife).
+type PaletteScheme struct {
+	name    string
+	palette []color.RGBA
+}
+
+// ImmigrationScheme is the two-color Immigration rule.
+var ImmigrationScheme = &PaletteScheme{
+	name: "immigration",
+	palette: []color.RGBA{
+		{R: 230, G: 60, B: 60, A: 255},
+		{R: 60, G: 120, B: 240, A: 255},
+	},
+}
+
+// QuadLifeScheme is the four-color QuadLife rule.
+var QuadLifeScheme = &PaletteScheme{
+	name: "quadlife",
+	palette: []color.RGBA{
+		{R: 230, G: 60, B: 60, A: 255},
+		{R: 60, G: 200, B: 80, A: 255},
+		{R: 60, G: 120, B: 240, A: 255},
+		{R: 240, G: 220, B: 60, A: 255},
+	},
+}
+
+func (s *PaletteScheme) Name() string { return s.name }
+
+func (s *PaletteScheme) Birth(parents []color.RGBA, rng *rand.Rand) color.RGBA {
+	if len(parents) == 0 {
+		return s.palette[rng.IntN(len(s.palette))]
+	}
+	if c, ok := majority(parents); ok {
+		return c
+	}
+
+	// All parents differ: take the first palette color none of them hold
+	for _, c := range s.palette {
+		held := false
+		for _, p := range parents {
+			if p == c {
+				held = true
+				break
+			}
+		}
+		if !held {
+			return c
+		}
+	}
+	return parents[rng.IntN(len(parents))]
+}
+
+func (s *PaletteScheme) CellColor(c CellInfo) (color.RGBA, bool) {
+	return c.Color, c.Alive
+}
+
+// Recolor maps an arbitrary color onto the palette. Palette colors are kept,
+// anything else is spread evenly across the palette.
+func (s *PaletteScheme) Recolor(c color.RGBA) color.RGBA {
+	for _, p := range s.palette {
+		if p == c {
+			return c
+		}
+	}
+	return s.palette[int(c.R^c.G^c.B)%len(s.palette)]
+}
+
+// majority returns the color held by more than one of the given colors, if any.
+func majority(colors []color.RGBA) (color.RGBA, bool) {
+	best, bestCount := color.RGBA{}, 1
+	for i, c := range colors {
+		count := 0
+		for _, o := range colors[i:] {
+			if o == c {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = c, count
+		}
+	}
+	return best, bestCount > 1
+}
@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"image/color"
+	"math/rand/v2"
+	"testing"
+)
+
+var (
+	red   = color.RGBA{R: 200, A: 255}
+	green = color.RGBA{G: 200, A: 255}
+	blue  = color.RGBA{B: 200, A: 255}
+)
+
+func TestInheritedBirthColors(t *testing.T) {
+	quad := QuadLifeScheme.palette
+	tests := []struct {
+		name    string
+		scheme  ColorScheme
+		parents []color.RGBA
+		want    color.RGBA
+	}{
+		{"majority of three", InheritMajorityScheme{}, []color.RGBA{red, blue, red}, red},
+		{"majority of two pairs picks the first", InheritMajorityScheme{}, []color.RGBA{blue, red, red, blue}, blue},
+		{"average", InheritAverageScheme{}, []color.RGBA{red, green, blue}, color.RGBA{R: 66, G: 66, B: 66, A: 255}},
+		{"immigration majority", ImmigrationScheme, []color.RGBA{ImmigrationScheme.palette[1], ImmigrationScheme.palette[0], ImmigrationScheme.palette[1]}, ImmigrationScheme.palette[1]},
+		{"quadlife majority", QuadLifeScheme, []color.RGBA{quad[2], quad[2], quad[0]}, quad[2]},
+		{"quadlife missing color", QuadLifeScheme, []color.RGBA{quad[0], quad[3], quad[1]}, quad[2]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.scheme.Birth(tt.parents, rand.New(rand.NewPCG(1, 1))); got != tt.want {
+				t.Errorf("born %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaletteRecolor(t *testing.T) {
+	for _, scheme := range []*PaletteScheme{ImmigrationScheme, QuadLifeScheme} {
+		for _, c := range scheme.palette {
+			if got := scheme.Recolor(c); got != c {
+				t.Errorf("%s: palette color %v recolored to %v", scheme.Name(), c, got)
+			}
+		}
+		for _, c := range []color.RGBA{red, green, blue, {}} {
+			got := scheme.Recolor(c)
+			if scheme.Recolor(got) != got {
+				t.Errorf("%s: %v recolored to %v, which is not in the palette", scheme.Name(), c, got)
+			}
+		}
+	}
+}
+
+func TestUniverseInheritance(t *testing.T) {
+	// The newborn corner of an L-tromino inherits the color of its parents
+	u := NewUniverse(16, 16)
+	cells := boardWith(16, 16, 5, 5, "##", "#.")
+	colors := blankColors(cells)
+	colors[5][5], colors[5][6], colors[6][5] = green, red, red
+	u.Load(cells, colors)
+	u.SetColorScheme(InheritMajorityScheme{})
+	u.Step()
+	if got := u.Color(6, 6); got != red {
+		t.Errorf("newborn cell colored %v, want %v", got, red)
+	}
+
+	// Selecting a palette scheme maps the board onto its palette
+	u.SetColorScheme(QuadLifeScheme)
+	for y := 5; y <= 6; y++ {
+		for x := 5; x <= 6; x++ {
+			if c := u.Color(x, y); QuadLifeScheme.Recolor(c) != c {
+				t.Errorf("cell %d, %d colored %v, outside the palette", x, y, c)
+			}
+		}
+	}
+}
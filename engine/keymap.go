@@ -0,0 +1,321 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// action is something the player can do with a key.
+type action int
+
+const (
+	actionHelp action = iota
+	actionToggleHUD
+	actionToggleGrid
+	actionNextPattern
+	actionZoomIn
+	actionZoomOut
+	actionFaster
+	actionSlower
+	actionPause
+	actionStepBack
+	actionStepForward
+	actionUndo
+	actionRedo
+	actionCopy
+	actionCut
+	actionPaste
+	actionClearSelection
+	actionFillSelection
+	actionRandomizeSelection
+	actionRotatePaste
+	actionFlipHorizontal
+	actionFlipVertical
+	actionPlacePattern
+	actionPreviousPhase
+	actionNextPhase
+	actionNextColors
+	actionToggleGraph
+	actionExportStats
+	actionScreenshot
+	actionBoardScreenshot
+	actionRecordGIF
+	actionRecordAPNG
+	actionBack
+	actionPaint1 // Through actionPaint1+8, to paint states 1 to 9
+	numActions   = actionPaint1 + maxPaintState
+)
+
+// maxPaintState is the highest state the palette offers to paint.
+const maxPaintState = 9
+
+// actionSpec describes an action: its name in key binding files, what it
+// does for the help overlay, and the keys it is bound to unless a file
+// says otherwise.
+type actionSpec struct {
+	name, description string
+	keys              []string
+}
+
+// actionSpecs describes every action, indexed by action.
+var actionSpecs = [numActions]actionSpec{
+	actionHelp:               {"help", "Show or hide this help", []string{"F1"}},
+	actionToggleHUD:          {"toggle-hud", "Cycle the HUD: full, status bar only, hidden", []string{"Tab"}},
+	actionToggleGrid:         {"toggle-grid", "Toggle grid lines, when cells are large enough", []string{"F2"}},
+	actionNextPattern:        {"next-pattern", "Next pattern; for 1D rules, single cell or random line", []string{"Space"}},
+	actionZoomIn:             {"zoom-in", "Increase the cell size", []string{"Equal", "NumpadAdd"}},
+	actionZoomOut:            {"zoom-out", "Decrease the cell size", []string{"Minus", "NumpadSubtract"}},
+	actionFaster:             {"faster", "Increase the tick speed", []string{"ArrowUp"}},
+	actionSlower:             {"slower", "Decrease the tick speed", []string{"ArrowDown"}},
+	actionPause:              {"pause", "Pause and resume", []string{"P"}},
+	actionStepBack:           {"step-back", "Step back one generation", []string{"ArrowLeft"}},
+	actionStepForward:        {"step-forward", "Step forward one generation", []string{"ArrowRight"}},
+	actionUndo:               {"undo", "Undo the last edit", []string{"Ctrl+Z"}},
+	actionRedo:               {"redo", "Redo the last undone edit", []string{"Ctrl+Y"}},
+	actionCopy:               {"copy", "Copy the selection", []string{"Ctrl+C"}},
+	actionCut:                {"cut", "Cut the selection", []string{"Ctrl+X"}},
+	actionPaste:              {"paste", "Paste", []string{"Ctrl+V"}},
+	actionClearSelection:     {"clear-selection", "Clear the selection", []string{"Delete", "Backspace"}},
+	actionFillSelection:      {"fill-selection", "Fill the selection", []string{"F"}},
+	actionRandomizeSelection: {"randomize-selection", "Randomize the selection, when not pasting", []string{"R"}},
+	actionRotatePaste:        {"rotate-paste", "Rotate while pasting", []string{"R"}},
+	actionFlipHorizontal:     {"flip-horizontal", "Flip horizontally while pasting", []string{"H"}},
+	actionFlipVertical:       {"flip-vertical", "Flip vertically while pasting", []string{"V"}},
+	actionPlacePattern:       {"place-pattern", "Place the next library pattern", []string{"L"}},
+	actionPreviousPhase:      {"previous-phase", "Step the pattern being placed back a phase", []string{"BracketLeft"}},
+	actionNextPhase:          {"next-phase", "Step the pattern being placed forward a phase", []string{"BracketRight"}},
+	actionNextColors:         {"next-colors", "Change the color scheme", []string{"C"}},
+	actionToggleGraph:        {"toggle-graph", "Toggle the population graph", []string{"G"}},
+	actionExportStats:        {"export-stats", "Export statistics", []string{"E"}},
+	actionScreenshot:         {"screenshot", "Save a screenshot of the window", []string{"F12"}},
+	actionBoardScreenshot:    {"board-screenshot", "Save a screenshot of the board only", []string{"Shift+F12"}},
+	actionRecordGIF:          {"record-gif", "Start or stop recording a GIF", []string{"F9"}},
+	actionRecordAPNG:         {"record-apng", "Start or stop recording an animated PNG", []string{"Shift+F9"}},
+	actionBack:               {"back", "Close help, cancel pasting, clear the selection, or exit", []string{"Escape"}},
+}
+
+func init() {
+	for state := 1; state <= maxPaintState; state++ {
+		actionSpecs[actionPaint1+action(state-1)] = actionSpec{
+			name:        fmt.Sprintf("paint-%d", state),
+			description: fmt.Sprintf("Paint state %d", state),
+			keys:        []string{fmt.Sprintf("Digit%d", state)},
+		}
+	}
+}
+
+// keyBinding is a key together with the modifiers that must be held with
+// it. Ctrl is either Control or Meta, the Command key on macOS.
+type keyBinding struct {
+	key              ebiten.Key
+	ctrl, shift, alt bool
+}
+
+// parseKeyBinding parses a binding such as "Ctrl+Shift+Z": modifiers, then
+// the name of a key as ebiten names it, joined by '+'.
+func parseKeyBinding(s string) (keyBinding, error) {
+	var b keyBinding
+	parts := strings.Split(s, "+")
+	for _, mod := range parts[:len(parts)-1] {
+		switch strings.ToLower(mod) {
+		case "ctrl", "control", "cmd", "meta":
+			b.ctrl = true
+		case "shift":
+			b.shift = true
+		case "alt":
+			b.alt = true
+		default:
+			return b, fmt.Errorf("unknown modifier '%s' in '%s'", mod, s)
+		}
+	}
+	if err := b.key.UnmarshalText([]byte(parts[len(parts)-1])); err != nil {
+		return b, fmt.Errorf("unknown key '%s' in '%s'", parts[len(parts)-1], s)
+	}
+	return b, nil
+}
+
+// String returns the binding as parseKeyBinding reads it.
+func (b keyBinding) String() string {
+	s := ""
+	for _, mod := range []struct {
+		held bool
+		name string
+	}{{b.ctrl, "Ctrl+"}, {b.shift, "Shift+"}, {b.alt, "Alt+"}} {
+		if mod.held {
+			s += mod.name
+		}
+	}
+	return s + b.key.String()
+}
+
+// modifiers returns how many modifiers the binding needs.
+func (b keyBinding) modifiers() int {
+	n := 0
+	for _, held := range []bool{b.ctrl, b.shift, b.alt} {
+		if held {
+			n++
+		}
+	}
+	return n
+}
+
+// keymap binds keys to actions and tells when actions are triggered. An
+// action triggers when one of its keys goes down while its modifiers are
+// held. Of the bindings of one key whose modifiers are all held at that
+// moment, only those with the most modifiers count, so Ctrl+C copies without
+// also changing the color scheme that C alone changes.
+type keymap struct {
+	bindings  [numActions][]keyBinding
+	input     keyInput
+	triggered [numActions]bool // Whether each action was triggered at the last poll
+}
+
+// keyInput is where a keymap reads the keyboard from: ebiten, or a fake one
+// in tests.
+type keyInput interface {
+	IsKeyPressed(key ebiten.Key) bool     // Whether the key is held
+	IsKeyJustPressed(key ebiten.Key) bool // Whether the key went down this tick
+}
+
+// ebitenInput reads the keyboard through ebiten.
+type ebitenInput struct{}
+
+func (ebitenInput) IsKeyPressed(key ebiten.Key) bool     { return ebiten.IsKeyPressed(key) }
+func (ebitenInput) IsKeyJustPressed(key ebiten.Key) bool { return inpututil.IsKeyJustPressed(key) }
+
+// defaultKeymap returns the keymap with the default bindings.
+func defaultKeymap() *keymap {
+	k := &keymap{input: ebitenInput{}}
+	for a, spec := range actionSpecs {
+		for _, key := range spec.keys {
+			b, err := parseKeyBinding(key)
+			if err != nil {
+				panic(err)
+			}
+			k.bindings[a] = append(k.bindings[a], b)
+		}
+	}
+	return k
+}
+
+// keymapFile is the layout of key binding files: a list of bindings for
+// each action that is not bound to its default keys. An empty list leaves
+// the action unbound.
+type keymapFile struct {
+	Bindings map[string][]string `json:"bindings"`
+}
+
+// load rebinds the actions named in a key binding file.
+func (k *keymap) load(r io.Reader) error {
+	var file keymapFile
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return err
+	}
+	for name, keys := range file.Bindings {
+		a, ok := actionNamed(name)
+		if !ok {
+			return fmt.Errorf("unknown action '%s'", name)
+		}
+		bindings := []keyBinding{}
+		for _, key := range keys {
+			b, err := parseKeyBinding(key)
+			if err != nil {
+				return fmt.Errorf("action '%s': %v", name, err)
+			}
+			bindings = append(bindings, b)
+		}
+		k.bindings[a] = bindings
+	}
+	return nil
+}
+
+// actionNamed returns the action with the given name.
+func actionNamed(name string) (action, bool) {
+	for a, spec := range actionSpecs {
+		if spec.name == name {
+			return action(a), true
+		}
+	}
+	return 0, false
+}
+
+// poll reads the keyboard. It is called once per Update, before any input
+// is handled.
+func (k *keymap) poll() {
+	held := keyBinding{
+		ctrl:  k.input.IsKeyPressed(ebiten.KeyControl) || k.input.IsKeyPressed(ebiten.KeyMeta),
+		shift: k.input.IsKeyPressed(ebiten.KeyShift),
+		alt:   k.input.IsKeyPressed(ebiten.KeyAlt),
+	}
+	matches := func(b keyBinding) bool {
+		return k.input.IsKeyJustPressed(b.key) && (!b.ctrl || held.ctrl) && (!b.shift || held.shift) && (!b.alt || held.alt)
+	}
+
+	// The most modifiers of the matching bindings of every key
+	most := map[ebiten.Key]int{}
+	for _, bindings := range k.bindings {
+		for _, b := range bindings {
+			if matches(b) {
+				most[b.key] = max(most[b.key], b.modifiers())
+			}
+		}
+	}
+
+	for a, bindings := range k.bindings {
+		k.triggered[a] = false
+		for _, b := range bindings {
+			if matches(b) && b.modifiers() == most[b.key] {
+				k.triggered[a] = true
+			}
+		}
+	}
+}
+
+// pressed reports whether an action was triggered at the last poll.
+func (k *keymap) pressed(a action) bool {
+	return k.triggered[a]
+}
+
+// keys returns the keys an action is bound to, for the help overlay.
+func (k *keymap) keys(a action) string {
+	names := make([]string, len(k.bindings[a]))
+	for i, b := range k.bindings[a] {
+		names[i] = b.String()
+	}
+	return strings.Join(names, " / ")
+}
+
+// LoadKeymap rebinds the actions named in a JSON key binding file, such as
+// {"bindings": {"pause": ["P", "Shift+Space"], "export-stats": []}}.
+// Actions the file does not name keep their default keys.
+func (g *Game) LoadKeymap(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := g.keys.load(file); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// WriteDefaultKeymap writes a key binding file with the default binding of
+// every action, as a starting point for rebinding them.
+func WriteDefaultKeymap(w io.Writer) error {
+	file := keymapFile{Bindings: map[string][]string{}}
+	for _, spec := range actionSpecs {
+		file.Bindings[spec.name] = spec.keys
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(file)
+}
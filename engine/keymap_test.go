@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// fakeInput is a keyboard for tests: the keys held, and of those the keys
+// that went down this tick.
+type fakeInput struct {
+	held, justPressed map[ebiten.Key]bool
+}
+
+func (f *fakeInput) IsKeyPressed(key ebiten.Key) bool     { return f.held[key] }
+func (f *fakeInput) IsKeyJustPressed(key ebiten.Key) bool { return f.justPressed[key] }
+
+// press holds the given keys, of which only the last goes down this tick,
+// and polls the keymap.
+func (f *fakeInput) press(k *keymap, keys ...ebiten.Key) {
+	f.held, f.justPressed = map[ebiten.Key]bool{}, map[ebiten.Key]bool{}
+	for _, key := range keys {
+		f.held[key] = true
+	}
+	if len(keys) > 0 {
+		f.justPressed[keys[len(keys)-1]] = true
+	}
+	k.poll()
+}
+
+// triggered returns the names of the actions triggered at the last poll.
+func triggered(k *keymap) []string {
+	var names []string
+	for a := range actionSpecs {
+		if k.pressed(action(a)) {
+			names = append(names, actionSpecs[a].name)
+		}
+	}
+	return names
+}
+
+func TestKeymapPoll(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []ebiten.Key
+		want string
+	}{
+		{"plain key", []ebiten.Key{ebiten.KeyC}, "next-colors"},
+		{"most modifiers win", []ebiten.Key{ebiten.KeyControl, ebiten.KeyC}, "copy"},
+		{"meta counts as ctrl", []ebiten.Key{ebiten.KeyMeta, ebiten.KeyC}, "copy"},
+		{"shifted binding", []ebiten.Key{ebiten.KeyShift, ebiten.KeyF9}, "record-apng"},
+		{"unbound modifier ignored", []ebiten.Key{ebiten.KeyAlt, ebiten.KeyF9}, "record-gif"},
+		{"key bound to two actions", []ebiten.Key{ebiten.KeyR}, "randomize-selection rotate-paste"},
+		{"second key of an action", []ebiten.Key{ebiten.KeyNumpadAdd}, "zoom-in"},
+		{"modifier alone", []ebiten.Key{ebiten.KeyControl}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := defaultKeymap()
+			input := &fakeInput{}
+			k.input = input
+			input.press(k, tt.keys...)
+			if got := strings.Join(triggered(k), " "); got != tt.want {
+				t.Errorf("triggered %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeymapTriggersOnce(t *testing.T) {
+	k := defaultKeymap()
+	input := &fakeInput{}
+	k.input = input
+
+	// Holding a key triggers its action only on the tick it goes down
+	input.press(k, ebiten.KeyP)
+	input.held[ebiten.KeyP], input.justPressed = true, nil
+	k.poll()
+	if k.pressed(actionPause) {
+		t.Error("pause triggered again while P is held")
+	}
+
+	// Modifiers pressed after the key do not trigger the modified binding
+	input.held[ebiten.KeyF9] = true
+	input.press(k, ebiten.KeyF9)
+	input.held[ebiten.KeyShift], input.justPressed = true, map[ebiten.Key]bool{ebiten.KeyShift: true}
+	k.poll()
+	if got := triggered(k); len(got) != 0 {
+		t.Errorf("pressing Shift while F9 is held triggered %v", got)
+	}
+
+	// Releasing a modifier does not trigger the binding without it
+	input.held, input.justPressed = map[ebiten.Key]bool{ebiten.KeyC: true}, nil
+	k.poll()
+	if got := triggered(k); len(got) != 0 {
+		t.Errorf("releasing Ctrl while C is held triggered %v", got)
+	}
+}
+
+func TestKeymapLoad(t *testing.T) {
+	k := defaultKeymap()
+	input := &fakeInput{}
+	k.input = input
+	if err := k.load(strings.NewReader(`{"bindings": {"pause": ["Shift+Space"], "next-colors": []}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := k.keys(actionPause); got != "Shift+Space" {
+		t.Errorf("pause bound to %q", got)
+	}
+	input.press(k, ebiten.KeyShift, ebiten.KeySpace)
+	if got := strings.Join(triggered(k), " "); got != "pause" {
+		t.Errorf("Shift+Space triggered %q, want pause", got)
+	}
+	input.press(k, ebiten.KeyC)
+	if got := triggered(k); len(got) != 0 {
+		t.Errorf("unbound C triggered %v", got)
+	}
+
+	for _, file := range []string{
+		`{"bindings": {"jump": ["J"]}}`,
+		`{"bindings": {"pause": ["Hyper+P"]}}`,
+		`{"bindings": {"pause": ["NoSuchKey"]}}`,
+		`{"keys": {}}`,
+	} {
+		if err := defaultKeymap().load(strings.NewReader(file)); err == nil {
+			t.Errorf("%s accepted", file)
+		}
+	}
+}
+
+func TestKeyBindingRoundTrip(t *testing.T) {
+	for _, spec := range actionSpecs {
+		for _, key := range spec.keys {
+			b, err := parseKeyBinding(key)
+			if err != nil {
+				t.Errorf("%s: %v", key, err)
+				continue
+			}
+			if got := b.String(); got != key {
+				t.Errorf("%s reads back as %s", key, got)
+			}
+		}
+	}
+}
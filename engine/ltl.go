@@ -0,0 +1,298 @@
+package engine
+
+import (
+	"fmt"
+	"image"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxRange is the largest neighborhood radius of Larger than Life rules.
+const maxRange = 100
+
+// LargerThanLifeRule is an outer-totalistic rule over a neighborhood of
+// extended range, written in the Larger than Life or HROT notation of Golly,
+// such as "R5,C0,M1,S34..58,B34..45,NM" for Bugs. As in Generations rules,
+// with more than two states cells that fail to survive pass through
+// refractory states first.
+type LargerThanLifeRule struct {
+	radius         int
+	states         int
+	middle         bool   // The cell itself counts towards its neighbors
+	shape          string // N part: "M", "N", "C" or "@" and a mask
+	birth, survive []bool // By number of live cells in the neighborhood
+	neighborhood   *Neighborhood
+	rects          []image.Rectangle // The neighborhood as rectangles for the summed-area table
+}
+
+// parseLargerThanLife parses a rule in Larger than Life or HROT notation: a
+// comma-separated list of the range R, the state count C (0 or 1 for two
+// states), whether the middle cell counts M, the survival and birth counts S
+// and B, and the neighborhood N, which is M for Moore, N for von Neumann, C
+// for circular or '@' and a mask as in ParseNeighborhoodMask. S and B take
+// counts and ranges written as "34..58" or "34-58", separated by commas.
+func parseLargerThanLife(s string) (*LargerThanLifeRule, error) {
+	r := &LargerThanLifeRule{radius: 1, states: 2, shape: "M"}
+	var survive, birth []string
+	var list *[]string // The S or B list that bare counts continue
+	seen := map[byte]bool{}
+	for _, part := range strings.Split(s, ",") {
+		if part == "" {
+			return nil, fmt.Errorf("empty part")
+		}
+		if part[0] >= '0' && part[0] <= '9' && list != nil {
+			*list = append(*list, part)
+			continue
+		}
+		tag, value := strings.ToUpper(part[:1])[0], part[1:]
+		if seen[tag] {
+			return nil, fmt.Errorf("'%c' given twice", tag)
+		}
+		seen[tag] = true
+		list = nil
+
+		var err error
+		switch tag {
+		case 'R':
+			r.radius, err = strconv.Atoi(value)
+			if err == nil && (r.radius < 1 || r.radius > maxRange) {
+				err = fmt.Errorf("range must be between 1 and %d", maxRange)
+			}
+		case 'C':
+			r.states, err = strconv.Atoi(value)
+			if err == nil && r.states < 2 {
+				r.states = 2
+			}
+		case 'M':
+			if value != "0" && value != "1" {
+				err = fmt.Errorf("M must be 0 or 1")
+			}
+			r.middle = value == "1"
+		case 'S', 'B':
+			list = &survive
+			if tag == 'B' {
+				list = &birth
+			}
+			if value != "" {
+				*list = append(*list, value)
+			}
+		case 'N':
+			r.shape = strings.ToUpper(value)
+			if strings.HasPrefix(value, "@") {
+				r.shape = "@" + strings.ToLower(value[1:])
+			}
+		default:
+			err = fmt.Errorf("unknown part '%s'", part)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !seen['R'] || !seen['S'] || !seen['B'] {
+		return nil, fmt.Errorf("R, S and B are required")
+	}
+	if r.states > 256 {
+		return nil, fmt.Errorf("state count must be at most 256")
+	}
+
+	offsets, err := r.offsets()
+	if err != nil {
+		return nil, err
+	}
+	r.neighborhood = newNeighborhood("N"+r.shape, offsets)
+	r.rects = rectangles(offsets)
+	if r.survive, err = parseCountRanges(survive, len(offsets)); err != nil {
+		return nil, err
+	}
+	if r.birth, err = parseCountRanges(birth, len(offsets)); err != nil {
+		return nil, err
+	}
+	if r.birth[0] {
+		return nil, fmt.Errorf("rules with B0 are not supported")
+	}
+	return r, nil
+}
+
+// offsets lists the cells of the neighborhood, including the middle cell if
+// it counts.
+func (r *LargerThanLifeRule) offsets() ([]image.Point, error) {
+	var offsets []image.Point
+	if mask, ok := strings.CutPrefix(r.shape, "@"); ok {
+		side := 2*r.radius + 1
+		if len(mask) != (side*side+3)/4 {
+			return nil, fmt.Errorf("neighborhood mask must have %d hex digits for range %d", (side*side+3)/4, r.radius)
+		}
+		n, err := ParseNeighborhoodMask(r.shape)
+		if err != nil {
+			return nil, err
+		}
+		offsets = slices.Clone(n.offsets)
+	} else {
+		var inside func(dx, dy int) bool
+		switch r.shape {
+		case "M":
+			inside = func(dx, dy int) bool { return true }
+		case "N":
+			inside = func(dx, dy int) bool { return abs(dx)+abs(dy) <= r.radius }
+		case "C":
+			// Cells whose centers lie within half a cell of the radius
+			inside = func(dx, dy int) bool { return dx*dx+dy*dy <= r.radius*r.radius+r.radius }
+		default:
+			return nil, fmt.Errorf("unknown neighborhood 'N%s'", r.shape)
+		}
+		for dy := -r.radius; dy <= r.radius; dy++ {
+			for dx := -r.radius; dx <= r.radius; dx++ {
+				if (dx != 0 || dy != 0) && inside(dx, dy) {
+					offsets = append(offsets, image.Pt(dx, dy))
+				}
+			}
+		}
+	}
+	if r.middle && !slices.Contains(offsets, image.Point{}) {
+		offsets = append(offsets, image.Point{})
+	}
+	return offsets, nil
+}
+
+// parseCountRanges parses the counts and ranges of counts of an S or B part
+// for a neighborhood of size cells.
+func parseCountRanges(items []string, size int) ([]bool, error) {
+	counts := make([]bool, size+1)
+	for _, item := range items {
+		lo, hi, isRange := strings.Cut(item, "..")
+		if !isRange {
+			lo, hi, isRange = strings.Cut(item, "-")
+		}
+		if !isRange {
+			hi = lo
+		}
+		from, err1 := strconv.Atoi(lo)
+		to, err2 := strconv.Atoi(hi)
+		if err1 != nil || err2 != nil || from > to {
+			return nil, fmt.Errorf("invalid count range '%s'", item)
+		}
+		if to > size {
+			return nil, fmt.Errorf("count %d is larger than the neighborhood of %d cells", to, size)
+		}
+		for c := from; c <= to; c++ {
+			counts[c] = true
+		}
+	}
+	return counts, nil
+}
+
+// rectangles covers the offsets with rectangles, merging runs of cells
+// within a row and then identical runs in consecutive rows. The Max corners
+// are exclusive.
+func rectangles(offsets []image.Point) []image.Rectangle {
+	sorted := slices.Clone(offsets)
+	slices.SortFunc(sorted, func(a, b image.Point) int {
+		if a.Y != b.Y {
+			return a.Y - b.Y
+		}
+		return a.X - b.X
+	})
+
+	var runs []image.Rectangle
+	for _, p := range sorted {
+		if n := len(runs); n > 0 && runs[n-1].Min.Y == p.Y && runs[n-1].Max.X == p.X {
+			runs[n-1].Max.X++
+			continue
+		}
+		runs = append(runs, image.Rect(p.X, p.Y, p.X+1, p.Y+1))
+	}
+
+	var rects []image.Rectangle
+	for _, run := range runs {
+		merged := false
+		for i := range rects {
+			r := &rects[i]
+			if r.Max.Y == run.Min.Y && r.Min.X == run.Min.X && r.Max.X == run.Max.X {
+				r.Max.Y++
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			rects = append(rects, run)
+		}
+	}
+	return rects
+}
+
+// String returns the rule in Larger than Life notation.
+func (r *LargerThanLifeRule) String() string {
+	states := r.states
+	if states == 2 {
+		states = 0
+	}
+	middle := 0
+	if r.middle {
+		middle = 1
+	}
+	return fmt.Sprintf("R%d,C%d,M%d,S%s,B%s,N%s",
+		r.radius, states, middle, countRangesString(r.survive), countRangesString(r.birth), r.shape)
+}
+
+// countRangesString writes the counts that are set as a comma-separated
+// list of counts and ranges.
+func countRangesString(counts []bool) string {
+	var items []string
+	for c := 0; c < len(counts); c++ {
+		if !counts[c] {
+			continue
+		}
+		start := c
+		for c+1 < len(counts) && counts[c+1] {
+			c++
+		}
+		if c == start {
+			items = append(items, strconv.Itoa(c))
+		} else {
+			items = append(items, fmt.Sprintf("%d..%d", start, c))
+		}
+	}
+	return strings.Join(items, ",")
+}
+
+func (r *LargerThanLifeRule) States() int { return r.states }
+
+func (r *LargerThanLifeRule) Neighborhood() *Neighborhood { return r.neighborhood }
+
+// Next counts the neighborhood cell by cell. Universes use the much faster
+// summed-area table instead.
+func (r *LargerThanLifeRule) Next(cells [][]uint8, x, y int) uint8 {
+	return r.next(cells[y][x], r.neighborhood.count(cells, x, y))
+}
+
+func (r *LargerThanLifeRule) nextFromSums(sums *summedArea, cells [][]uint8, x, y int) uint8 {
+	state := cells[y][x]
+	if state > 1 {
+		return r.next(state, 0)
+	}
+	count := 0
+	for _, rect := range r.rects {
+		count += sums.count(x, y, rect)
+	}
+	return r.next(state, count)
+}
+
+// next returns the next state of a cell in the given state with count live
+// cells in its neighborhood.
+func (r *LargerThanLifeRule) next(state uint8, count int) uint8 {
+	switch {
+	case state == 0:
+		if r.birth[count] {
+			return 1
+		}
+		return 0
+	case state == 1 && r.survive[count]:
+		return 1
+	}
+	// Start or continue dying
+	if int(state)+1 >= r.states {
+		return 0
+	}
+	return state + 1
+}
@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLargerThanLifeRoundTrip(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"R5,C0,M1,S34..58,B34..45,NM", "R5,C0,M1,S34..58,B34..45,NM"},
+		{"r2,c3,m0,s2-4,6,b3,nn", "R2,C3,M0,S2..4,6,B3,NN"},
+		{"R3,C2,M0,S5,6,7,B4..5,NC", "R3,C0,M0,S5..7,B4..5,NC"},
+		{"R1,C0,M0,S2,3,B3,N@1EF", "R1,C0,M0,S2..3,B3,N@1ef"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := ParseRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rule.String() != tt.want {
+				t.Fatalf("written as %q, want %q", rule.String(), tt.want)
+			}
+			again, err := ParseRule(rule.String())
+			if err != nil || again.String() != tt.want {
+				t.Errorf("reparsed as %v, %v", again, err)
+			}
+		})
+	}
+}
+
+func TestLargerThanLifeErrors(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"R0,C0,M0,S2,B3,NM", "range must be between 1 and 100"},
+		{"R101,C0,M0,S2,B3,NM", "range must be between 1 and 100"},
+		{"R2,C0,M2,S2,B3,NM", "M must be 0 or 1"},
+		{"R2,C0,M0,S2,NM", "R, S and B are required"},
+		{"R2,C0,M0,B3,NM", "R, S and B are required"},
+		{"R2,R3,C0,M0,S2,B3,NM", "given twice"},
+		{"R2,C0,M0,S2,,B3,NM", "empty part"},
+		{"R2,C0,M0,S2,B3,NX", "unknown neighborhood"},
+		{"R2,C0,M0,S2,B3,N@1ff", "must have 7 hex digits"},
+		{"R2,C0,M0,S5..3,B3,NM", "invalid count range"},
+		{"R1,C0,M0,S2,B3..9,NM", "count 9 is larger than the neighborhood of 8 cells"},
+		{"R2,C0,M0,S2,B0..3,NM", "B0"},
+		{"R2,C300,M0,S2,B3,NM", "at most 256"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			_, err := ParseRule(tt.rule)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLargerThanLifeSums(t *testing.T) {
+	// The last column of tiles is narrower than the range, so changes reach
+	// one tile further across the wrapped-around edge
+	cells, _ := soup(100, 70, 0.4, 5)
+	for _, rule := range []string{
+		"R5,C0,M1,S34..58,B34..45,NM",
+		"R3,C0,M0,S5..10,B6..8,NN",
+		"R4,C4,M1,S20..38,B25..30,NC",
+		"R2,C0,M0,S3..5,B4..6,N@0a8822a",
+	} {
+		t.Run(rule, func(t *testing.T) {
+			r, err := ParseRule(rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertStepsMatch(t, r, cells)
+		})
+	}
+}
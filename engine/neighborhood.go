@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"fmt"
+	"image"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+// Neighborhood is the set of cells, relative to a cell, whose states decide
+// its next state. Neighborhoods are immutable once built.
+type Neighborhood struct {
+	name    string        // Rulestring suffix, empty for Moore
+	offsets []image.Point // Relative positions of the neighbors, nearest first
+	radius  int           // Largest distance of a neighbor along either axis
+}
+
+var (
+	// Moore is the 3x3 block of eight cells around a cell.
+	Moore = newNeighborhood("", []image.Point{
+		{-1, -1}, {0, -1}, {1, -1},
+		{-1, 0}, {1, 0},
+		{-1, 1}, {0, 1}, {1, 1},
+	})
+	// VonNeumann is the four orthogonally adjacent cells.
+	VonNeumann = newNeighborhood("V", []image.Point{
+		{0, -1}, {-1, 0}, {1, 0}, {0, 1},
+	})
+	// Hexagonal is the six neighbors of a hexagonal grid mapped onto the
+	// square one: the Moore neighborhood without the top-right and
+	// bottom-left corners. Each row is then drawn half a cell to the right
+	// of the one below it.
+	Hexagonal = newNeighborhood("H", []image.Point{
+		{-1, -1}, {0, -1},
+		{-1, 0}, {1, 0},
+		{0, 1}, {1, 1},
+	})
+)
+
+// newNeighborhood builds a neighborhood from the offsets of its cells.
+func newNeighborhood(name string, offsets []image.Point) *Neighborhood {
+	slices.SortStableFunc(offsets, func(a, b image.Point) int {
+		return (a.X*a.X + a.Y*a.Y) - (b.X*b.X + b.Y*b.Y)
+	})
+	n := &Neighborhood{name: name, offsets: offsets}
+	for _, o := range offsets {
+		n.radius = max(n.radius, abs(o.X), abs(o.Y))
+	}
+	return n
+}
+
+// ParseNeighborhoodMask parses a custom neighborhood written as '@' followed
+// by hex digits. Read as a binary number, the digits cover the (2r+1)x(2r+1)
+// square around a cell row by row, most significant bit first, so "@1ba" is
+// the 3x3 mask 110 111 010. With the center bit set, a live cell counts
+// itself. The radius r follows from the number of digits.
+func ParseNeighborhoodMask(s string) (*Neighborhood, error) {
+	digits, ok := strings.CutPrefix(s, "@")
+	if !ok || digits == "" {
+		return nil, fmt.Errorf("neighborhood mask must be '@' followed by hex digits")
+	}
+	mask, ok := new(big.Int).SetString(digits, 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid hex digits in neighborhood mask '%s'", s)
+	}
+
+	radius := -1
+	for r := 1; (2*r+1)*(2*r+1) <= 4*len(digits); r++ {
+		if side := 2*r + 1; (side*side+3)/4 == len(digits) {
+			radius = r
+		}
+	}
+	if radius < 0 {
+		return nil, fmt.Errorf("neighborhood mask '%s' does not have the length of a square", s)
+	}
+
+	side := 2*radius + 1
+	if mask.BitLen() > side*side {
+		return nil, fmt.Errorf("neighborhood mask '%s' has bits outside the %dx%d square", s, side, side)
+	}
+	var offsets []image.Point
+	for i := 0; i < side*side; i++ {
+		if mask.Bit(side*side-1-i) != 0 {
+			offsets = append(offsets, image.Pt(i%side-radius, i/side-radius))
+		}
+	}
+	if len(offsets) == 0 {
+		return nil, fmt.Errorf("neighborhood mask '%s' has no cells", s)
+	}
+	return newNeighborhood("N@"+strings.ToLower(digits), offsets), nil
+}
+
+// Name returns the suffix that selects the neighborhood in a rulestring: ""
+// for Moore, "V" for von Neumann, "H" for hexagonal, or "N" followed by the
+// mask for custom neighborhoods. The neighborhoods of Larger than Life rules
+// are named after their N part, such as "NM".
+func (n *Neighborhood) Name() string { return n.name }
+
+// Size returns the number of cells in the neighborhood.
+func (n *Neighborhood) Size() int { return len(n.offsets) }
+
+// Radius returns the largest distance of a neighbor along either axis. No
+// cell can affect cells further away than this in one generation.
+func (n *Neighborhood) Radius() int { return n.radius }
+
+// Hexagonal reports whether the neighborhood models a hexagonal grid, which
+// is drawn with every row shifted by half a cell.
+func (n *Neighborhood) Hexagonal() bool { return n == Hexagonal }
+
+// count returns the number of neighbors of (x, y) in state 1, wrapping
+// around the edges of the board.
+func (n *Neighborhood) count(cells [][]uint8, x, y int) int {
+	height, width := len(cells), len(cells[0])
+	count := 0
+	for _, o := range n.offsets {
+		if cells[wrap(y+o.Y, height)][wrap(x+o.X, width)] == 1 {
+			count++
+		}
+	}
+	return count
+}
+
+// wrap maps a coordinate that may lie off the board back onto it.
+func wrap(v, size int) int {
+	return ((v % size) + size) % size
+}
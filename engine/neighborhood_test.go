@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"image"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseNeighborhoodMask(t *testing.T) {
+	tests := []struct {
+		mask    string
+		radius  int
+		offsets []image.Point
+	}{
+		{"@1ba", 1, []image.Point{{0, 0}, {0, -1}, {-1, 0}, {1, 0}, {0, 1}, {-1, -1}}},
+		{"@0a8822a", 2, []image.Point{{-1, -2}, {1, -2}, {-2, -1}, {2, -1}, {-2, 1}, {2, 1}, {-1, 2}, {1, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mask, func(t *testing.T) {
+			n, err := ParseNeighborhoodMask(tt.mask)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n.Radius() != tt.radius || !slices.Equal(n.offsets, tt.offsets) {
+				t.Errorf("radius %d, offsets %v, want %d, %v", n.Radius(), n.offsets, tt.radius, tt.offsets)
+			}
+		})
+	}
+}
+
+func TestNeighborhoodRuleErrors(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"B2/S23N@", "'@' followed by hex digits"},
+		{"B2/S23N@1b", "does not have the length of a square"},
+		{"B2/S23N@3ff", "has bits outside the 3x3 square"},
+		{"B2/S23N@000", "has no cells"},
+		{"B2/S23N@1ffffff", "neighborhood has 25 cells"},
+		{"B2/S23N@0a8a2aa", "neighborhood has 10 cells"},
+		{"B2a/S12V", "Hensel notation ('a') only apply to the Moore neighborhood"},
+		{"B2/S1-cH", "Hensel notation ('-') only apply to the Moore neighborhood"},
+		{"B5/S1V", "invalid neighbor count '5' for a neighborhood of 4 cells"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			_, err := ParseRule(tt.rule)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeighborhoodRoundTrip(t *testing.T) {
+	for _, s := range []string{"B1/S1V", "B2/S34H", "B2/S/C3H", "B3/S23N@1ba", "B3/S2N@0a8822a"} {
+		t.Run(s, func(t *testing.T) {
+			rule, err := ParseRule(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rule.String(); got != s {
+				t.Errorf("parsed and written back as %s", got)
+			}
+		})
+	}
+}
+
+func TestNeighborhoodNext(t *testing.T) {
+	// Each board has one cell in the middle and decides its next state
+	tests := []struct {
+		name  string
+		rule  string
+		cells [][]uint8
+		want  uint8
+	}{
+		{"von Neumann ignores corners", "B2/SV", boardWith(3, 3, 0, 0, "#.#", "...", "..."), 0},
+		{"von Neumann counts edges", "B2/SV", boardWith(3, 3, 0, 0, ".#.", "#..", "..."), 1},
+		{"hexagonal ignores the top right", "B1/SH", boardWith(3, 3, 0, 0, "..#", "...", "..."), 0},
+		{"hexagonal counts the top left", "B1/SH", boardWith(3, 3, 0, 0, "#..", "...", "..."), 1},
+		{"custom mask counts the center", "B/S1N@1ba", boardWith(3, 3, 0, 0, "...", ".#.", "..."), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rule.Next(tt.cells, 1, 1); got != tt.want {
+				t.Errorf("middle cell becomes %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
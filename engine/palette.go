@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Palette geometry, in screen pixels
+const (
+	paletteSwatch = 16
+	paletteGap    = 6
+	paletteMargin = 10
+)
+
+var paletteHighlight = color.RGBA{R: 255, G: 255, B: 0, A: 255}
+
+// handlePaletteInput lets the paint actions, the number keys by default,
+// pick the state that clicking and dragging paint, for rules with more than
+// two states.
+func (g *Game) handlePaletteInput() {
+	for state := 1; state <= maxPaintState; state++ {
+		if g.keys.pressed(actionPaint1+action(state-1)) && state < g.universe.Rule().States() {
+			g.paintState = uint8(state)
+		}
+	}
+	if int(g.paintState) >= g.universe.Rule().States() {
+		g.paintState = 1
+	}
+}
+
+// beginStroke starts painting at the given screen position as a new
+// undoable edit. Clicking a cell that already has the paint state clears
+// it instead, and so does the rest of the stroke.
+func (g *Game) beginStroke(mx, my int) {
+	g.cellSizeMutex.Lock()
+	cellSize := g.cellSize
+	g.cellSizeMutex.Unlock()
+
+	x, y, ok := g.screenToCell(mx, my, cellSize)
+	if !ok {
+		return
+	}
+	g.strokeState = g.paintState
+	if g.universe.State(x, y) == g.paintState {
+		g.strokeState = 0
+	}
+	g.painting = true
+	g.strokeX, g.strokeY = x, y
+	g.universe.Apply([]CellChange{{X: x, Y: y, State: g.strokeState, Color: g.universe.BirthColor()}})
+}
+
+// continueStroke paints the cells between the end of the stroke and the
+// given screen position, as part of the same edit.
+func (g *Game) continueStroke(mx, my int) {
+	g.cellSizeMutex.Lock()
+	cellSize := g.cellSize
+	g.cellSizeMutex.Unlock()
+
+	x, y, ok := g.screenToCell(mx, my, cellSize)
+	if !ok || (x == g.strokeX && y == g.strokeY) {
+		return
+	}
+	var changes []CellChange
+	for _, p := range cellLine(g.strokeX, g.strokeY, x, y)[1:] {
+		changes = append(changes, CellChange{X: p.X, Y: p.Y, State: g.strokeState, Color: g.universe.BirthColor()})
+	}
+	g.strokeX, g.strokeY = x, y
+	g.universe.Extend(changes)
+}
+
+// cellLine returns the cells on the line from (x0, y0) to (x1, y1), both
+// included, so that fast mouse movements still paint unbroken wires.
+func cellLine(x0, y0, x1, y1 int) []image.Point {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	points := []image.Point{{x0, y0}}
+	for err := dx + dy; x0 != x1 || y0 != y1; {
+		if e2 := 2 * err; e2 >= dy {
+			err += dy
+			x0 += sx
+		} else {
+			err += dx
+			y0 += sy
+		}
+		points = append(points, image.Pt(x0, y0))
+	}
+	return points
+}
+
+// drawPalette draws a swatch for every state the number keys can pick in
+// the top-right corner, with the state being painted highlighted. Rules
+// with two states have nothing to pick, so it draws nothing for them.
+func (g *Game) drawPalette(screen *ebiten.Image) {
+	states := min(g.universe.Rule().States(), maxPaintState+1)
+	if states <= 2 {
+		return
+	}
+	x := float32(g.screenWidth - paletteMargin - (states-1)*(paletteSwatch+paletteGap) + paletteGap)
+	for state := 1; state < states; state++ {
+		vector.DrawFilledRect(screen, x, paletteMargin, paletteSwatch, paletteSwatch, g.universe.StateColor(uint8(state)), false)
+		if uint8(state) == g.paintState {
+			vector.StrokeRect(screen, x-2, paletteMargin-2, paletteSwatch+4, paletteSwatch+4, 2, paletteHighlight, false)
+		}
+		ebitenutil.DebugPrintAt(screen, fmt.Sprint(state), int(x)+5, paletteMargin+paletteSwatch+2)
+		x += paletteSwatch + paletteGap
+	}
+}
@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"github.com/jared-wallace/gol/patterns"
+)
+
+// Orientation is one of the eight symmetries of the square, applied to a
+// pattern before it is placed.
+type Orientation struct {
+	Flip     bool // Mirror left to right before rotating
+	Rotation int  // Clockwise quarter turns
+}
+
+// ClipFromPattern converts a library pattern into a clip. Live cells get random colors.
+func ClipFromPattern(p *patterns.Pattern) *Clip {
+	clip := NewClip(p.Width, p.Height)
+	for _, c := range p.Cells {
+		clip.Cells[c.Y][c.X] = uint8(max(c.State, 1))
+		clip.Colors[c.Y][c.X] = randomColor()
+	}
+	return clip
+}
+
+// Orient returns a copy of the clip with the orientation applied.
+func (c *Clip) Orient(o Orientation) *Clip {
+	oriented := c.Clone()
+	if o.Flip {
+		oriented.FlipHorizontal()
+	}
+	for i := 0; i < ((o.Rotation%4)+4)%4; i++ {
+		oriented.Rotate()
+	}
+	return oriented
+}
+
+// Advance returns the clip as it looks after evolving in isolation under the
+// rule for the given number of generations, cropped to its live cells. This
+// selects the phase of an oscillator or spaceship before it is placed.
+func (c *Clip) Advance(rule Rule, generations int) *Clip {
+	if generations <= 0 {
+		return c.Clone()
+	}
+
+	// Nothing travels faster than light, which covers one neighborhood radius
+	// per generation, so this margin keeps the pattern from wrapping
+	pad := (generations + 1) * rule.Neighborhood().Radius()
+	u := NewUniverse(c.Width+2*pad, c.Height+2*pad)
+	u.SetRule(rule)
+	u.Apply(c.Changes(pad, pad))
+	for i := 0; i < generations; i++ {
+		u.Step()
+	}
+
+	s, _ := u.Stats().Latest()
+	if s.Population == 0 {
+		return NewClip(0, 0)
+	}
+	return u.Region(s.MinX, s.MinY, s.MaxX-s.MinX+1, s.MaxY-s.MinY+1)
+}
+
+// Stamp sets the live cells of the clip with its top-left corner at (x, y)
+// as a single undoable edit, leaving the rest of the board untouched.
+func (u *Universe) Stamp(clip *Clip, x, y int) {
+	u.Apply(clip.Changes(x, y))
+}
+
+// Place stamps a library pattern onto the existing board with its top-left
+// corner at (x, y), after orienting it and advancing it to the given phase
+// under the universe's rule.
+func (u *Universe) Place(p *patterns.Pattern, x, y int, o Orientation, phase int) {
+	u.Stamp(ClipFromPattern(p).Orient(o).Advance(u.rule, phase), x, y)
+}
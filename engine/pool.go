@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"image/color"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+)
+
+// worker holds the state owned by one pool goroutine. Jobs receive the
+// worker running them, so nothing in it is ever shared between goroutines.
+type worker struct {
+	rng     *rand.Rand
+	parents []color.RGBA // Scratch space for the colors of a newborn's parents
+}
+
+// workerPool runs tick jobs on a fixed set of long-lived goroutines shared
+// by every universe, instead of spawning new goroutines every generation.
+type workerPool struct {
+	size int
+	jobs chan func(*worker)
+}
+
+var (
+	poolMutex     sync.Mutex
+	sharedPool    *workerPool
+	workersWanted int // 0 means runtime.GOMAXPROCS
+)
+
+// SetWorkers sets the number of goroutines used to compute generations.
+// Zero or a negative number sizes the pool from runtime.GOMAXPROCS.
+func SetWorkers(n int) {
+	poolMutex.Lock()
+	defer poolMutex.Unlock()
+	workersWanted = max(n, 0)
+	if sharedPool != nil && sharedPool.size != poolSize() {
+		sharedPool.stop()
+		sharedPool = nil
+	}
+}
+
+// Workers returns the number of goroutines used to compute generations.
+func Workers() int {
+	poolMutex.Lock()
+	defer poolMutex.Unlock()
+	return poolSize()
+}
+
+// poolSize returns the pool size that was asked for. poolMutex must be held.
+func poolSize() int {
+	if workersWanted > 0 {
+		return workersWanted
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// getPool returns the shared pool, starting it on first use.
+func getPool() *workerPool {
+	poolMutex.Lock()
+	defer poolMutex.Unlock()
+	if sharedPool == nil {
+		sharedPool = newWorkerPool(poolSize())
+	}
+	return sharedPool
+}
+
+// newWorkerPool starts size goroutines waiting for jobs.
+func newWorkerPool(size int) *workerPool {
+	p := &workerPool{size: size, jobs: make(chan func(*worker), size*4)}
+	for i := 0; i < size; i++ {
+		go func() {
+			w := &worker{rng: newRNG(), parents: make([]color.RGBA, 0, 8)}
+			for job := range p.jobs {
+				job(w)
+			}
+		}()
+	}
+	return p
+}
+
+// stop lets the goroutines exit once the queued jobs are done.
+func (p *workerPool) stop() {
+	close(p.jobs)
+}
+
+// run calls job once for every index in [0, n), spread over the pool, and
+// waits for all of them to finish.
+func (p *workerPool) run(n int, job func(i int, w *worker)) {
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		p.jobs <- func(w *worker) {
+			defer wg.Done()
+			job(i, w)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,307 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"slices"
+	"time"
+)
+
+// cropMargin is the number of empty cells kept around the live cells when
+// an animation is cropped.
+const cropMargin = 2
+
+// AnimationOptions control how a Recorder writes its frames.
+type AnimationOptions struct {
+	CellSize int           // Pixels per cell
+	Grid     bool          // Draw lines between cells, if they are at least minGridCellSize pixels
+	Crop     bool          // Only show the box the live cells stayed within, instead of the whole board
+	Delay    time.Duration // Time each frame is shown
+}
+
+// Default limits of a recording. Frames take two bytes per cell, so on a
+// board of 1000x1000 cells the byte limit is reached after 268 frames.
+const (
+	DefaultMaxRecordingFrames = 10000
+	DefaultMaxRecordingBytes  = 512 << 20
+)
+
+// ErrRecordingFull is returned by Capture once a recording has as many
+// frames or bytes as it may have.
+var ErrRecordingFull = errors.New("recording is full")
+
+// Recorder collects generations of a universe as the frames of an animation,
+// which it writes as an animated GIF or APNG. Frames are kept as one color
+// index of two bytes per cell until the animation is written, so a
+// recording takes no more frames once it reaches its limits.
+type Recorder struct {
+	maxFrames     int
+	maxBytes      int
+	bytes         int // Size of the frames recorded
+	width, height int
+	hex           bool
+	colors        []color.RGBA // Colors of the recording, the background first
+	counts        []int        // How many cells of all frames have each color
+	index         map[color.RGBA]uint16
+	frames        [][]uint16 // Color of every cell, row by row
+	bounds        image.Rectangle
+}
+
+// NewRecorder returns a recorder without frames that takes up to maxFrames
+// frames and maxBytes bytes of them. The first frame is always taken.
+func NewRecorder(maxFrames, maxBytes int) *Recorder {
+	return &Recorder{
+		maxFrames: maxFrames,
+		maxBytes:  maxBytes,
+		colors:    []color.RGBA{backgroundColor},
+		counts:    []int{0},
+		index:     map[color.RGBA]uint16{backgroundColor: 0},
+	}
+}
+
+// Len returns the number of frames recorded.
+func (r *Recorder) Len() int {
+	return len(r.frames)
+}
+
+// Capture adds the current generation of the universe as a frame, in the
+// colors it is drawn in. The board must keep its size for the whole
+// recording. Once the recording is full, Capture returns an error wrapping
+// ErrRecordingFull and keeps the frames it has.
+func (r *Recorder) Capture(u *Universe) error {
+	size := 2 * u.width * u.height
+	if len(r.frames) == 0 {
+		r.width, r.height = u.width, u.height
+		r.hex = u.rule.Neighborhood().Hexagonal()
+	} else if u.width != r.width || u.height != r.height {
+		return fmt.Errorf("board changed size from %dx%d to %dx%d while recording", r.width, r.height, u.width, u.height)
+	} else if len(r.frames) >= r.maxFrames || r.bytes+size > r.maxBytes {
+		return fmt.Errorf("%w after %d frames", ErrRecordingFull, len(r.frames))
+	}
+
+	frame := make([]uint16, u.width*u.height)
+	for y := 0; y < u.height; y++ {
+		for x := 0; x < u.width; x++ {
+			col, ok := u.CellColor(x, y)
+			if !ok {
+				r.counts[0]++
+				continue
+			}
+			i, seen := r.index[col]
+			if !seen {
+				if len(r.colors) > 0xffff {
+					i = r.nearest(col)
+				} else {
+					i = uint16(len(r.colors))
+					r.colors = append(r.colors, col)
+					r.counts = append(r.counts, 0)
+				}
+				r.index[col] = i
+			}
+			r.counts[i]++
+			frame[y*u.width+x] = i
+			r.bounds = r.bounds.Union(image.Rect(x, y, x+1, y+1))
+		}
+	}
+	r.frames = append(r.frames, frame)
+	r.bytes += size
+	return nil
+}
+
+// nearest returns the index of the recorded color closest to col.
+func (r *Recorder) nearest(col color.RGBA) uint16 {
+	best, bestDistance := 0, -1
+	for i, c := range r.colors {
+		dr, dg, db := int(c.R)-int(col.R), int(c.G)-int(col.G), int(c.B)-int(col.B)
+		if d := dr*dr + dg*dg + db*db; bestDistance < 0 || d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+	return uint16(best)
+}
+
+// region returns the cells the frames show.
+func (r *Recorder) region(crop bool) image.Rectangle {
+	board := image.Rect(0, 0, r.width, r.height)
+	if !crop || r.bounds.Empty() {
+		return board
+	}
+	return r.bounds.Inset(-cropMargin).Intersect(board)
+}
+
+// frame draws frame i.
+func (r *Recorder) frame(i int, opts AnimationOptions) *image.RGBA {
+	cells := r.frames[i]
+	style := cellStyle{cellSize: opts.CellSize, grid: opts.Grid, hex: r.hex, wrap: !opts.Crop}
+	return style.draw(r.region(opts.Crop), r.height, func(x, y int) (color.RGBA, bool) {
+		c := cells[y*r.width+x]
+		return r.colors[c], c != 0
+	})
+}
+
+// palette returns the colors GIF frames are drawn in: all colors of the
+// recording if they fit into 256, and otherwise the background, the grid
+// and the most common others.
+func (r *Recorder) palette(grid bool) color.Palette {
+	order := make([]int, len(r.colors)-1)
+	for i := range order {
+		order[i] = i + 1
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return r.counts[b] - r.counts[a] })
+	palette := color.Palette{r.colors[0]}
+	if grid {
+		palette = append(palette, gridColor)
+	}
+	for _, i := range order[:min(len(order), 256-len(palette))] {
+		palette = append(palette, r.colors[i])
+	}
+	return palette
+}
+
+// WriteGIF writes the frames as an animated GIF that loops forever.
+func (r *Recorder) WriteGIF(w io.Writer, opts AnimationOptions) error {
+	if len(r.frames) == 0 {
+		return errors.New("nothing was recorded")
+	}
+	palette := r.palette(opts.Grid && opts.CellSize >= minGridCellSize)
+	indices := map[color.RGBA]uint8{}
+	anim := &gif.GIF{}
+	delay := int(opts.Delay / (10 * time.Millisecond))
+	for i := range r.frames {
+		img := r.frame(i, opts)
+		paletted := image.NewPaletted(img.Rect, palette)
+		for p := 0; p < len(img.Pix); p += 4 {
+			col := color.RGBA{R: img.Pix[p], G: img.Pix[p+1], B: img.Pix[p+2], A: img.Pix[p+3]}
+			index, ok := indices[col]
+			if !ok {
+				index = uint8(palette.Index(col))
+				indices[col] = index
+			}
+			paletted.Pix[p/4] = index
+		}
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, delay)
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// pngSignature starts every PNG file.
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// WriteAPNG writes the frames as an animated PNG that loops forever, in full
+// color. Frames are encoded one at a time.
+func (r *Recorder) WriteAPNG(w io.Writer, opts AnimationOptions) error {
+	if len(r.frames) == 0 {
+		return errors.New("nothing was recorded")
+	}
+	if _, err := w.Write(pngSignature); err != nil {
+		return err
+	}
+	delay := uint16(opts.Delay / time.Millisecond)
+	sequence := uint32(0)
+	var buf bytes.Buffer
+	for i := range r.frames {
+		img := r.frame(i, opts)
+		buf.Reset()
+		if err := png.Encode(&buf, img); err != nil {
+			return err
+		}
+		chunks, err := pngChunks(buf.Bytes())
+		if err != nil {
+			return err
+		}
+
+		if i == 0 {
+			// The header of the first frame, then the animation control chunk
+			if err := writePNGChunk(w, "IHDR", chunks[0].data); err != nil {
+				return err
+			}
+			actl := binary.BigEndian.AppendUint32(nil, uint32(len(r.frames)))
+			actl = binary.BigEndian.AppendUint32(actl, 0) // Loop forever
+			if err := writePNGChunk(w, "acTL", actl); err != nil {
+				return err
+			}
+		}
+
+		fctl := binary.BigEndian.AppendUint32(nil, sequence)
+		fctl = binary.BigEndian.AppendUint32(fctl, uint32(img.Rect.Dx()))
+		fctl = binary.BigEndian.AppendUint32(fctl, uint32(img.Rect.Dy()))
+		fctl = binary.BigEndian.AppendUint32(fctl, 0) // x offset
+		fctl = binary.BigEndian.AppendUint32(fctl, 0) // y offset
+		fctl = binary.BigEndian.AppendUint16(fctl, delay)
+		fctl = binary.BigEndian.AppendUint16(fctl, 1000) // Delay in milliseconds
+		fctl = append(fctl, 0, 0)                        // Neither dispose nor blend
+		if err := writePNGChunk(w, "fcTL", fctl); err != nil {
+			return err
+		}
+		sequence++
+
+		for _, c := range chunks {
+			if c.kind != "IDAT" {
+				continue
+			}
+			var err error
+			if i == 0 {
+				err = writePNGChunk(w, "IDAT", c.data)
+			} else {
+				err = writePNGChunk(w, "fdAT", binary.BigEndian.AppendUint32(nil, sequence), c.data)
+				sequence++
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return writePNGChunk(w, "IEND", nil)
+}
+
+// pngChunk is a chunk of a PNG file.
+type pngChunk struct {
+	kind string
+	data []byte
+}
+
+// pngChunks splits an encoded PNG file into its chunks, the IHDR first.
+func pngChunks(file []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(file, pngSignature) {
+		return nil, errors.New("not a PNG file")
+	}
+	var chunks []pngChunk
+	for rest := file[len(pngSignature):]; len(rest) >= 12; {
+		n := int(binary.BigEndian.Uint32(rest))
+		if len(rest) < 12+n {
+			return nil, errors.New("truncated PNG chunk")
+		}
+		chunks = append(chunks, pngChunk{kind: string(rest[4:8]), data: rest[8 : 8+n]})
+		rest = rest[12+n:]
+	}
+	if len(chunks) == 0 || chunks[0].kind != "IHDR" {
+		return nil, errors.New("PNG file does not start with IHDR")
+	}
+	return chunks, nil
+}
+
+// writePNGChunk writes a chunk of the given kind whose data is the
+// concatenation of parts.
+func writePNGChunk(w io.Writer, kind string, parts ...[]byte) error {
+	n := 0
+	for _, p := range parts {
+		n += len(p)
+	}
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(n))
+	chunk = append(chunk, kind...)
+	for _, p := range parts {
+		chunk = append(chunk, p...)
+	}
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	_, err := w.Write(chunk)
+	return err
+}
@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+// recordGlider records frames generations of a glider on a 16x12 board.
+func recordGlider(t *testing.T, r *Recorder, frames int) {
+	t.Helper()
+	cells := boardWith(16, 12, 2, 2, glider...)
+	u := NewUniverse(16, 12)
+	u.Load(cells, blankColors(cells))
+	u.SetColorScheme(MonochromeScheme{})
+	for i := 0; i < frames; i++ {
+		if err := r.Capture(u); err != nil {
+			t.Fatal(err)
+		}
+		u.Step()
+	}
+}
+
+func TestRecorderLimits(t *testing.T) {
+	const frameBytes = 2 * 16 * 12
+	tests := []struct {
+		name                string
+		maxFrames, maxBytes int
+		want                int
+	}{
+		{"frames", 5, 100 * frameBytes, 5},
+		{"bytes", 100, 3*frameBytes + frameBytes/2, 3},
+		{"first frame always", 100, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRecorder(tt.maxFrames, tt.maxBytes)
+			recordGlider(t, r, tt.want)
+			cells := boardWith(16, 12, 0, 0)
+			u := NewUniverse(16, 12)
+			u.Load(cells, blankColors(cells))
+			if err := r.Capture(u); !errors.Is(err, ErrRecordingFull) {
+				t.Errorf("capture after %d frames returned %v, want ErrRecordingFull", r.Len(), err)
+			}
+			if r.Len() != tt.want {
+				t.Errorf("%d frames kept, want %d", r.Len(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRecorderSizeChange(t *testing.T) {
+	r := NewRecorder(DefaultMaxRecordingFrames, DefaultMaxRecordingBytes)
+	recordGlider(t, r, 2)
+	if err := r.Capture(NewUniverse(8, 8)); err == nil || errors.Is(err, ErrRecordingFull) {
+		t.Errorf("capture of another board size returned %v", err)
+	}
+}
+
+func TestWriteGIF(t *testing.T) {
+	r := NewRecorder(DefaultMaxRecordingFrames, DefaultMaxRecordingBytes)
+	recordGlider(t, r, 8)
+	tests := []struct {
+		name string
+		opts AnimationOptions
+		size image.Point
+	}{
+		{"whole board", AnimationOptions{CellSize: 2}, image.Pt(32, 24)},
+		// Eight generations take the glider from 2..4 to 4..6, plus the margin
+		{"cropped", AnimationOptions{CellSize: 3, Crop: true}, image.Pt(27, 27)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := r.WriteGIF(&buf, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			anim, err := gif.DecodeAll(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(anim.Image) != 8 {
+				t.Fatalf("%d frames, want 8", len(anim.Image))
+			}
+			if got := anim.Image[0].Bounds().Size(); got != tt.size {
+				t.Errorf("frames of %v, want %v", got, tt.size)
+			}
+		})
+	}
+}
+
+func TestWriteAPNG(t *testing.T) {
+	r := NewRecorder(DefaultMaxRecordingFrames, DefaultMaxRecordingBytes)
+	recordGlider(t, r, 4)
+	var buf bytes.Buffer
+	if err := r.WriteAPNG(&buf, AnimationOptions{CellSize: 2}); err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := pngChunks(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := map[string]int{}
+	for _, c := range chunks {
+		count[c.kind]++
+		if c.kind == "acTL" {
+			if frames := binary.BigEndian.Uint32(c.data); frames != 4 {
+				t.Errorf("acTL announces %d frames, want 4", frames)
+			}
+		}
+	}
+	if count["acTL"] != 1 || count["fcTL"] != 4 || count["IDAT"] == 0 || count["fdAT"] < 3 || count["IEND"] != 1 {
+		t.Errorf("chunks %v", count)
+	}
+	if chunks[len(chunks)-1].kind != "IEND" {
+		t.Error("the file does not end with IEND")
+	}
+}
+
+func TestCellStyleUnwrapped(t *testing.T) {
+	// Without wrapping, the shifted rows of a hex grid widen the image
+	// rather than wrapping around, and the bottom row of the region is not
+	// shifted
+	style := cellStyle{cellSize: 4, hex: true}
+	region := image.Rect(1, 1, 3, 3)
+	img := style.draw(region, 5, func(x, y int) (color.RGBA, bool) {
+		return monochromeColor, x == 2
+	})
+	if got := img.Bounds(); got != image.Rect(0, 0, 10, 8) {
+		t.Fatalf("image bounds %v, want 10x8", got)
+	}
+	for _, p := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{6, 0, monochromeColor}, {9, 3, monochromeColor}, {5, 0, backgroundColor},
+		{4, 4, monochromeColor}, {7, 7, monochromeColor}, {8, 4, backgroundColor},
+	} {
+		if got := img.RGBAAt(p.x, p.y); got != p.want {
+			t.Errorf("pixel %d, %d is %v, want %v", p.x, p.y, got, p.want)
+		}
+	}
+}
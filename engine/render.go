@@ -0,0 +1,195 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+
+	"github.com/jared-wallace/gol/patterns"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// RenderOptions control how Render draws a universe.
+type RenderOptions struct {
+	CellSize int  // Pixels per cell
+	Grid     bool // Draw lines between cells, if they are at least minGridCellSize pixels
+	HUD      bool // Print what the game's HUD says about the universe in the top-left corner
+}
+
+// minGridCellSize is the smallest cell size grid lines are drawn at, which
+// leaves cells at least two pixels after the lines.
+const minGridCellSize = 3
+
+// Colors of the background, grid lines and text in rendered images, and of
+// the box behind the text.
+var (
+	backgroundColor     = color.RGBA{A: 255}
+	gridColor           = color.RGBA{R: 64, G: 64, B: 64, A: 255}
+	textColor           = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	textBackgroundColor = color.RGBA{A: 160}
+)
+
+// Render draws the whole board into an image, the way the game draws it: in
+// the colors of the current color scheme, with the rows of hexagonal rules
+// shifted into a hex grid that wraps around the right edge.
+func (u *Universe) Render(opts RenderOptions) *image.RGBA {
+	board := image.Rect(0, 0, u.width, u.height)
+	style := cellStyle{cellSize: opts.CellSize, grid: opts.Grid, hex: u.rule.Neighborhood().Hexagonal(), wrap: true}
+	img := style.draw(board, u.height, u.CellColor)
+	if opts.HUD {
+		drawText(img, u.hudLines(""))
+	}
+	return img
+}
+
+// cellStyle is how cells are drawn into images.
+type cellStyle struct {
+	cellSize int  // Pixels per cell
+	grid     bool // Lines between cells, if they are at least minGridCellSize pixels
+	hex      bool // Each row half a cell to the right of the one below, as on a hex grid
+	wrap     bool // Rows shifted past the right edge wrap around, rather than widening the image
+}
+
+// draw draws the cells of region, a rectangle of a board of the given
+// height, into a new image. colorAt gives the color of a cell, or false if
+// it is not drawn.
+func (s cellStyle) draw(region image.Rectangle, boardHeight int, colorAt func(x, y int) (color.RGBA, bool)) *image.RGBA {
+	cellSize := max(s.cellSize, 1)
+	width := region.Dx() * cellSize
+	shift := func(y int) int { return 0 }
+	if s.hex {
+		shift = func(y int) int { return (boardHeight - 1 - y) * cellSize / 2 }
+		if !s.wrap {
+			base := shift(region.Max.Y - 1)
+			shift = func(y int) int { return (boardHeight-1-y)*cellSize/2 - base }
+			width += shift(region.Min.Y)
+		}
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, region.Dy()*cellSize))
+	draw.Draw(img, img.Bounds(), image.NewUniform(backgroundColor), image.Point{}, draw.Src)
+
+	for y := region.Min.Y; y < region.Max.Y; y++ {
+		top := (y - region.Min.Y) * cellSize
+		for x := region.Min.X; x < region.Max.X; x++ {
+			left := (x-region.Min.X)*cellSize + shift(y)
+			if col, ok := colorAt(x, y); ok {
+				fillCell(img, left, top, cellSize, col)
+			}
+			if s.grid && cellSize >= minGridCellSize {
+				// Lines along the top and left edges of the cell
+				for d := 0; d < cellSize; d++ {
+					img.SetRGBA(wrap(left+d, width), top, gridColor)
+					img.SetRGBA(wrap(left, width), top+d, gridColor)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// hudLines returns the lines of the HUD that describe the universe, with
+// the given note after the detection status.
+func (u *Universe) hudLines(statusNote string) []string {
+	lines := []string{
+		fmt.Sprintf("Rule: %s", u.rule),
+		fmt.Sprintf("Colors: %s", u.scheme.Name()),
+		fmt.Sprintf("Generation: %d", u.generation),
+		fmt.Sprintf("Population: %d", u.Population()),
+		fmt.Sprintf("Status: %s%s", u.Detection(), statusNote),
+	}
+	if _, ok := u.rule.(*StochasticRule); ok || u.UpdateMode() != SyncUpdate {
+		lines = append(lines, fmt.Sprintf("Update: %s, seed %d", u.UpdateMode(), u.seed))
+	}
+	return lines
+}
+
+// drawText prints lines of text in the top-left corner of an image, in the
+// fixed-width font basicfont.Face7x13, on a translucent box.
+func drawText(img draw.Image, lines []string) {
+	face := basicfont.Face7x13
+	width := 0
+	for _, line := range lines {
+		width = max(width, font.MeasureString(face, line).Ceil())
+	}
+	const margin = 4
+	box := image.Rect(0, 0, width+2*margin, len(lines)*face.Height+2*margin)
+	draw.Draw(img, box, image.NewUniform(textBackgroundColor), image.Point{}, draw.Over)
+
+	d := font.Drawer{Dst: img, Src: image.NewUniform(textColor), Face: face}
+	for i, line := range lines {
+		d.Dot = fixed.P(margin, margin+i*face.Height+face.Ascent)
+		d.DrawString(line)
+	}
+}
+
+// fillCell fills a square of size pixels with its top-left corner at (x, y),
+// wrapping around the right edge of the image.
+func fillCell(img *image.RGBA, x, y, size int, col color.RGBA) {
+	width := img.Rect.Dx()
+	for dy := 0; dy < size; dy++ {
+		for dx := 0; dx < size; dx++ {
+			img.SetRGBA(wrap(x+dx, width), y+dy, col)
+		}
+	}
+}
+
+// WritePNG saves an image as a PNG file.
+func WritePNG(path string, img image.Image) error {
+	return writeFile(path, func(file *os.File) error { return png.Encode(file, img) })
+}
+
+// writeFile creates a file and fills it with write, closing it either way.
+func writeFile(path string, write func(file *os.File) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// LoadPattern fills the board with the named library pattern, centered, or
+// with a random soup for "random", under the rule the pattern file names or
+// else the given one. One-dimensional rules start from a single cell, or
+// from a random line for "random", as in the game. It is meant for driving
+// universes without the GUI.
+func (u *Universe) LoadPattern(name string, rule Rule) error {
+	if line, ok := rule.(*WolframRule); ok {
+		u.SetRule(line)
+		u.Load(line.Seed(u.width, u.height, name == "random"), makeGrid[color.RGBA](u.width, u.height))
+		return nil
+	}
+	if name == "random" {
+		u.SetRule(rule)
+		cells, colors, _ := patterns.RandomConfig(u.height, u.width)
+		u.Load(cells, colors)
+		return nil
+	}
+
+	p, err := patterns.LoadPattern(name)
+	if err != nil {
+		return err
+	}
+	if p.Rule != "" {
+		if rule, err = ParseRule(p.Rule); err != nil {
+			return fmt.Errorf("pattern '%s': %v", name, err)
+		}
+	}
+	u.SetRule(rule)
+	cells, colors, _, err := patterns.LoadPatternConfig(u.height, u.width, name)
+	var tooLarge *patterns.PatternTooLargeError
+	if err != nil && !errors.As(err, &tooLarge) {
+		return err
+	}
+	u.Load(cells, colors)
+	return err
+}
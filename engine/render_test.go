@@ -0,0 +1,134 @@
+package engine
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// renderBoard renders a board under the rule in monochrome.
+func renderBoard(t *testing.T, rule string, cells [][]uint8, opts RenderOptions) *image.RGBA {
+	t.Helper()
+	r, err := ParseRule(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := NewUniverse(len(cells[0]), len(cells))
+	u.SetRule(r)
+	u.Load(cells, blankColors(cells))
+	u.SetColorScheme(MonochromeScheme{})
+	return u.Render(opts)
+}
+
+func TestRender(t *testing.T) {
+	type pixel struct {
+		x, y int
+		want color.RGBA
+	}
+	tests := []struct {
+		name   string
+		rule   string
+		cells  [][]uint8
+		opts   RenderOptions
+		pixels []pixel
+	}{
+		{"cells", "life", boardWith(4, 3, 1, 1, "#"), RenderOptions{CellSize: 3}, []pixel{
+			{3, 3, monochromeColor}, {5, 5, monochromeColor},
+			{2, 3, backgroundColor}, {6, 5, backgroundColor}, {3, 6, backgroundColor},
+		}},
+		{"grid", "life", boardWith(4, 3, 1, 1, "#"), RenderOptions{CellSize: 4, Grid: true}, []pixel{
+			{4, 4, gridColor}, {7, 4, gridColor}, {4, 7, gridColor}, {5, 5, monochromeColor},
+			{7, 7, monochromeColor}, {0, 0, gridColor}, {1, 1, backgroundColor},
+		}},
+		{"no grid on small cells", "life", boardWith(4, 3, 1, 1, "#"), RenderOptions{CellSize: 2, Grid: true}, []pixel{
+			{2, 2, monochromeColor}, {0, 0, backgroundColor},
+		}},
+		{"hexagonal rows shifted", "B2/S34H", boardWith(4, 2, 3, 0, "#"), RenderOptions{CellSize: 4}, []pixel{
+			// The top row is shifted right by half a cell and wraps around
+			{14, 0, monochromeColor}, {15, 3, monochromeColor}, {0, 0, monochromeColor}, {1, 3, monochromeColor},
+			{2, 0, backgroundColor}, {13, 0, backgroundColor},
+		}},
+		{"hexagonal bottom row in place", "B2/S34H", boardWith(4, 2, 0, 1, "#"), RenderOptions{CellSize: 4}, []pixel{
+			{0, 4, monochromeColor}, {3, 7, monochromeColor}, {4, 4, backgroundColor},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := renderBoard(t, tt.rule, tt.cells, tt.opts)
+			size := max(tt.opts.CellSize, 1)
+			if got, want := img.Bounds(), image.Rect(0, 0, len(tt.cells[0])*size, len(tt.cells)*size); got != want {
+				t.Fatalf("image bounds %v, want %v", got, want)
+			}
+			for _, p := range tt.pixels {
+				if got := img.RGBAAt(p.x, p.y); got != p.want {
+					t.Errorf("pixel %d, %d is %v, want %v", p.x, p.y, got, p.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderHUD(t *testing.T) {
+	// A full board, so that the box behind the text shows
+	cells := makeGrid[uint8](100, 40)
+	for y := range cells {
+		for x := range cells[y] {
+			cells[y][x] = 1
+		}
+	}
+	plain := renderBoard(t, "life", cells, RenderOptions{CellSize: 4})
+	img := renderBoard(t, "life", cells, RenderOptions{CellSize: 4, HUD: true})
+
+	// The text sits on a box in the top-left corner and leaves the rest alone
+	var changed image.Rectangle
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			if img.RGBAAt(x, y) != plain.RGBAAt(x, y) {
+				changed = changed.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	text := 0
+	for y := changed.Min.Y; y < changed.Max.Y; y++ {
+		for x := changed.Min.X; x < changed.Max.X; x++ {
+			if img.RGBAAt(x, y) == textColor {
+				text++
+			}
+		}
+	}
+	if text == 0 {
+		t.Fatal("no text printed")
+	}
+	if changed.Min != (image.Point{}) {
+		t.Errorf("text box starts at %v, want the top-left corner", changed.Min)
+	}
+	// One line for each of the rule, colors, generation, population and status
+	if lines := 5; changed.Dy() < lines*13 || changed.Dy() > (lines+1)*13 {
+		t.Errorf("text box %d pixels high, want %d lines", changed.Dy(), lines)
+	}
+}
+
+func TestLoadPatternCropsBoard(t *testing.T) {
+	// The rule is set before the soup is made, so the soup fits the board
+	// the block rule crops
+	u := NewUniverse(31, 17)
+	if err := u.LoadPattern("random", mustParseRule(t, "bbm")); err != nil {
+		t.Fatal(err)
+	}
+	if u.Width() != 30 || u.Height() != 16 {
+		t.Errorf("board of %dx%d, want 30x16", u.Width(), u.Height())
+	}
+	if got := u.Render(RenderOptions{}).Bounds(); got != image.Rect(0, 0, 30, 16) {
+		t.Errorf("image bounds %v, want 30x16", got)
+	}
+}
+
+// mustParseRule parses a rulestring.
+func mustParseRule(t *testing.T, s string) Rule {
+	t.Helper()
+	rule, err := ParseRule(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rule
+}
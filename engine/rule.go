@@ -0,0 +1,295 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Rule decides how cells change from one generation to the next. Cell states
+// run from 0, the dead background, to States()-1. Next is called by the tick
+// workers concurrently, so implementations must be safe for concurrent use.
+type Rule interface {
+	// String returns the rulestring, as written in RLE headers.
+	String() string
+	// States returns the number of cell states, including the dead state.
+	States() int
+	// Neighborhood returns the cells that Next looks at.
+	Neighborhood() *Neighborhood
+	// Next returns the next state of the cell at (x, y). Neighbors wrap
+	// around the edges of the board.
+	Next(cells [][]uint8, x, y int) uint8
+}
+
+// Life is Conway's Game of Life.
+var Life Rule = mustParseGenerations("B3/S23")
+
+// builtinRules maps the names of rules that have no rulestring to them.
+var builtinRules = map[string]Rule{
+	"wireworld": WireWorld,
+	"sand":      Sand,
+}
+
+// namedRules maps well-known rule names to their rulestrings.
+var namedRules = map[string]string{
+	"life":         "B3/S23",
+	"highlife":     "B36/S23",
+	"tlife":        "B3/S2-i34q",
+	"brians-brain": "B2/S/C3",
+	"star-wars":    "B2/S345/C4",
+	"bugs":         "R5,C0,M1,S34..58,B34..45,NM",
+	"rule30":       "W30",
+	"rule90":       "W90",
+	"rule110":      "W110",
+	"bbm":          "MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14;15",
+	"critters":     "MS,D15;14;13;3;11;5;6;1;7;9;10;2;12;4;8;0",
+	"tron":         "MS,D15;1;2;3;4;5;6;7;8;9;10;11;12;13;14;0",
+	"hpp-gas":      "MS,D0;8;4;12;2;10;9;14;1;6;5;13;3;11;7;15",
+}
+
+// ParseRule parses a rulestring. Besides the names in namedRules it accepts
+// B/S notation ("B3/S23"), S/B notation ("23/3"), isotropic non-totalistic
+// rules in Hensel notation ("B2-a/S12", "B3/S2-i34q"), and Generations rules
+// in any of these with a state count ("B2/S/C3", "/2/3", "345/2/4"). A
+// suffix selects another neighborhood: V for von Neumann ("B2/S013V"), H for
+// hexagonal ("B2/S34H"), or N followed by a mask for a custom one
+// ("B2/S23N@1ba"), see ParseNeighborhoodMask. Rules of extended range are
+// written in Larger than Life notation ("R5,C0,M1,S34..58,B34..45,NM"), and
+// one-dimensional rules by their Wolfram code ("W30", "T1599,K3"). Block
+// rules on the Margolus neighborhood are written in MCell notation
+// ("MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14;15"). Any of these followed by
+// '~' and probabilities is a StochasticRule ("B3/S23~b0.9~n0.001").
+// Anything else is taken as the name of a Golly .rule file in RuleDirs
+// ("Langtons-Loops"), or as the path of one if it ends in ".rule". WireWorld
+// and Sand are built in.
+func ParseRule(s string) (Rule, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty rule")
+	}
+	if base, spec, ok := strings.Cut(s, "~"); ok {
+		r, err := ParseRule(base)
+		if err != nil {
+			return nil, err
+		}
+		stochastic, err := parseStochastic(r, spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule '%s': %v", s, err)
+		}
+		return stochastic, nil
+	}
+	if r, ok := builtinRules[strings.ToLower(s)]; ok {
+		return r, nil
+	}
+	if named, ok := namedRules[strings.ToLower(s)]; ok {
+		s = named
+	}
+	var r Rule
+	var err error
+	switch strings.ToUpper(s)[0] {
+	case 'R':
+		r, err = parseLargerThanLife(s)
+	case 'W', 'T':
+		r, err = parseWolfram(s)
+	case 'M':
+		r, err = parseMargolus(s)
+	default:
+		r, err = parseGenerations(s)
+	}
+	if err != nil {
+		fileRule, found, fileErr := findRuleFile(s)
+		if found {
+			return fileRule, fileErr
+		}
+		return nil, fmt.Errorf("invalid rule '%s': %v", s, err)
+	}
+	return r, nil
+}
+
+// GenerationsRule is a Life-like rule, outer-totalistic or isotropic
+// non-totalistic, in which cells that fail to survive do not die straight
+// away but pass through refractory states, during which they neither
+// survive nor count as neighbors. With two states it is an ordinary
+// Life-like rule.
+type GenerationsRule struct {
+	birth, survive             henselTable // By configuration, for the Moore neighborhood
+	birthCounts, surviveCounts []bool      // By neighbor count, for the other neighborhoods
+	neighborhood               *Neighborhood
+	states                     int
+}
+
+// mustParseGenerations parses a rulestring that is known to be valid.
+func mustParseGenerations(s string) *GenerationsRule {
+	r, err := parseGenerations(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// parseGenerations parses a rulestring in B/S/C or S/B/C notation, with an
+// optional neighborhood suffix.
+func parseGenerations(s string) (*GenerationsRule, error) {
+	s, neighborhood, err := cutNeighborhood(s)
+	if err != nil {
+		return nil, err
+	}
+	if neighborhood.Size() > maxCountDigit {
+		return nil, fmt.Errorf("neighborhood has %d cells, but neighbor counts are single digits, so at most %d are supported",
+			neighborhood.Size(), maxCountDigit)
+	}
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("expected two or three parts separated by '/'")
+	}
+
+	r := &GenerationsRule{states: 2, neighborhood: neighborhood}
+	upper := strings.ToUpper(s)
+	if strings.HasPrefix(upper, "B") || strings.HasPrefix(upper, "S") {
+		// B/S/C notation, in any order
+		for _, part := range parts {
+			if part == "" {
+				return nil, fmt.Errorf("empty part")
+			}
+			tag, digits := strings.ToUpper(part[:1]), part[1:]
+			switch tag {
+			case "B":
+				err = r.parseConditions(digits, &r.birth, &r.birthCounts)
+			case "S":
+				err = r.parseConditions(digits, &r.survive, &r.surviveCounts)
+			case "C", "G":
+				r.states, err = strconv.Atoi(digits)
+			default:
+				err = fmt.Errorf("unknown part '%s'", part)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		// S/B/C notation
+		if err := r.parseConditions(parts[0], &r.survive, &r.surviveCounts); err != nil {
+			return nil, err
+		}
+		if err := r.parseConditions(parts[1], &r.birth, &r.birthCounts); err != nil {
+			return nil, err
+		}
+		if len(parts) == 3 {
+			if r.states, err = strconv.Atoi(parts[2]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if r.states < 2 || r.states > 256 {
+		return nil, fmt.Errorf("state count must be between 2 and 256")
+	}
+	if r.birth[0] || len(r.birthCounts) > 0 && r.birthCounts[0] {
+		return nil, fmt.Errorf("rules with B0 are not supported")
+	}
+	return r, nil
+}
+
+// cutNeighborhood splits the neighborhood suffix off a rulestring. Without
+// one the neighborhood is Moore.
+func cutNeighborhood(s string) (string, *Neighborhood, error) {
+	upper := strings.ToUpper(s)
+	if i := strings.LastIndex(upper, "N@"); i >= 0 {
+		n, err := ParseNeighborhoodMask(s[i+1:])
+		return s[:i], n, err
+	}
+	switch {
+	case strings.HasSuffix(upper, "M"):
+		return s[:len(s)-1], Moore, nil
+	case strings.HasSuffix(upper, "V"):
+		return s[:len(s)-1], VonNeumann, nil
+	case strings.HasSuffix(upper, "H"):
+		return s[:len(s)-1], Hexagonal, nil
+	}
+	return s, Moore, nil
+}
+
+// maxCountDigit is the largest neighbor count a B or S part can name, since
+// every count is written as one digit.
+const maxCountDigit = 9
+
+// parseConditions parses the neighbor conditions of a B or S part. Under the
+// Moore neighborhood they go into table and may use Hensel notation; under
+// the others they are plain neighbor counts and go into counts.
+func (r *GenerationsRule) parseConditions(spec string, table *henselTable, counts *[]bool) error {
+	if r.neighborhood == Moore {
+		var err error
+		*table, err = parseHensel(spec)
+		return err
+	}
+	*counts = make([]bool, r.neighborhood.Size()+1)
+	for _, d := range spec {
+		if d >= 'a' && d <= 'z' || d == '-' {
+			return fmt.Errorf("neighbor configurations in Hensel notation ('%c') only apply to the Moore neighborhood", d)
+		}
+		count := int(d - '0')
+		if d < '0' || d > '9' || count >= len(*counts) {
+			return fmt.Errorf("invalid neighbor count '%c' for a neighborhood of %d cells", d, r.neighborhood.Size())
+		}
+		(*counts)[count] = true
+	}
+	return nil
+}
+
+// String returns the rule in B/S notation, with the state count for
+// Generations rules.
+func (r *GenerationsRule) String() string {
+	var s string
+	if r.neighborhood == Moore {
+		s = "B" + r.birth.String() + "/S" + r.survive.String()
+	} else {
+		s = "B" + countsString(r.birthCounts) + "/S" + countsString(r.surviveCounts)
+	}
+	if r.states > 2 {
+		s += "/C" + strconv.Itoa(r.states)
+	}
+	return s + r.neighborhood.Name()
+}
+
+// countsString lists the neighbor counts that are set.
+func countsString(counts []bool) string {
+	var b strings.Builder
+	for count, set := range counts {
+		if set {
+			b.WriteString(strconv.Itoa(count))
+		}
+	}
+	return b.String()
+}
+
+func (r *GenerationsRule) States() int { return r.states }
+
+func (r *GenerationsRule) Neighborhood() *Neighborhood { return r.neighborhood }
+
+func (r *GenerationsRule) Next(cells [][]uint8, x, y int) uint8 {
+	state := cells[y][x]
+	switch {
+	case state == 0:
+		if r.matches(&r.birth, r.birthCounts, cells, x, y) {
+			return 1
+		}
+		return 0
+	case state == 1:
+		if r.matches(&r.survive, r.surviveCounts, cells, x, y) {
+			return 1
+		}
+	}
+	// Start or continue dying
+	if int(state)+1 >= r.states {
+		return 0
+	}
+	return state + 1
+}
+
+// matches reports whether the neighbors of (x, y) meet the conditions of a B
+// or S part.
+func (r *GenerationsRule) matches(table *henselTable, counts []bool, cells [][]uint8, x, y int) bool {
+	if r.neighborhood == Moore {
+		return table[mooreConfig(cells, x, y)]
+	}
+	return counts[r.neighborhood.count(cells, x, y)]
+}
@@ -0,0 +1,128 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHenselRoundTrip(t *testing.T) {
+	for _, s := range []string{"B2-a/S12", "B3/S2-i34q", "B2ce3-k/S", "B3/S23", "B2ae3/S2-in3"} {
+		t.Run(s, func(t *testing.T) {
+			rule, err := ParseRule(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rule.String(); got != s {
+				t.Errorf("parsed and written back as %s", got)
+			}
+		})
+	}
+}
+
+func TestHenselErrors(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"B9/S23", "invalid neighbor count '9'"},
+		{"B3/S2z", "'z' is not a configuration of 2 neighbors"},
+		{"B3/S1k", "'k' is not a configuration of 1 neighbors"},
+		{"B3-/S23", "'-' after 3 is not followed by letters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			_, err := ParseRule(tt.rule)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsotropicNext(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		cells [][]uint8
+		want  uint8
+	}{
+		{"2i survives", "life", boardWith(3, 3, 0, 0, ".#.", ".#.", ".#."), 1},
+		{"2i dies", "tlife", boardWith(3, 3, 0, 0, ".#.", ".#.", ".#."), 0},
+		{"2a survives", "life", boardWith(3, 3, 0, 0, ".##", ".#.", "..."), 1},
+		{"2a survives", "tlife", boardWith(3, 3, 0, 0, ".##", ".#.", "..."), 1},
+		{"2i born", "B2-a/S12", boardWith(3, 3, 0, 0, "...", "#.#", "..."), 1},
+		{"2a not born", "B2-a/S12", boardWith(3, 3, 0, 0, "##.", "...", "..."), 0},
+		{"1 survives", "B2-a/S12", boardWith(3, 3, 0, 0, "#..", ".#.", "..."), 1},
+		{"rotated 2a not born", "B2-a/S12", boardWith(3, 3, 0, 0, "..#", "..#", "..."), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule+"/"+tt.name, func(t *testing.T) {
+			rule, err := ParseRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rule.Next(tt.cells, 1, 1); got != tt.want {
+				t.Errorf("middle cell becomes %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHenselMatchesTotalistic(t *testing.T) {
+	// Listing every configuration of a count is the same as the bare count
+	hensel, err := ParseRule("B3cekaiynjqr/S2cekain3cekaiynjqr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cells, _ := soup(64, 64, 0.35, 5)
+	for i := 0; i < 20; i++ {
+		want := nextBoard(Life, cells)
+		got := nextBoard(hensel, cells)
+		for y := range want {
+			for x := range want[y] {
+				if got[y][x] != want[y][x] {
+					t.Fatalf("generation %d: cell %d, %d is %d, want %d", i+1, x, y, got[y][x], want[y][x])
+				}
+			}
+		}
+		cells = want
+	}
+}
+
+func TestTLifeDiverges(t *testing.T) {
+	// The middle cell of a blinker has its two neighbors opposite each
+	// other, so under tlife it dies rather than the blinker turning, and the
+	// two cells born beside it die out
+	tests := []struct {
+		rule        string
+		generation1 [][]uint8
+		population2 int
+	}{
+		{"life", boardWith(5, 5, 0, 0, ".....", ".....", ".###.", ".....", "....."), 3},
+		{"tlife", boardWith(5, 5, 0, 0, ".....", ".....", ".#.#.", ".....", "....."), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := ParseRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			u := NewUniverse(5, 5)
+			u.SetRule(rule)
+			cells := boardWith(5, 5, 2, 1, "#", "#", "#")
+			u.Load(cells, blankColors(cells))
+			u.Step()
+			for y, row := range tt.generation1 {
+				for x, want := range row {
+					if got := u.State(x, y); got != want {
+						t.Fatalf("generation 1: cell %d, %d is %d, want %d", x, y, got, want)
+					}
+				}
+			}
+			u.Step()
+			if got := u.Population(); got != tt.population2 {
+				t.Errorf("generation 2: population %d, want %d", got, tt.population2)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// RuleDirs are the directories searched for NAME.rule files when a rule is
+// given by a name that is neither built in nor valid rule notation.
+var RuleDirs = []string{"rules"}
+
+// stateColorer is implemented by rules that give every state its own color,
+// which is drawn instead of the color scheme's.
+type stateColorer interface {
+	stateColor(state uint8) color.RGBA
+}
+
+// stateNamer is implemented by rules whose states have names.
+type stateNamer interface {
+	stateName(state uint8) string
+}
+
+// Default colors of rule files without an @COLORS section, spread from
+// state 1 to the last state as in Golly.
+var (
+	ruleFileFirstColor = color.RGBA{R: 255, A: 255}
+	ruleFileLastColor  = color.RGBA{R: 255, G: 255, A: 255}
+)
+
+// ruleFile holds what a Golly .rule file says about a rule besides its
+// transitions.
+type ruleFile struct {
+	name   string
+	states int
+	colors []color.RGBA
+}
+
+func (f *ruleFile) String() string { return f.name }
+
+func (f *ruleFile) States() int { return f.states }
+
+func (f *ruleFile) stateColor(state uint8) color.RGBA {
+	return f.colors[state]
+}
+
+// LoadRuleFile reads a Golly .rule file from disk.
+func LoadRuleFile(path string) (Rule, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadRuleFile(file)
+}
+
+// ReadRuleFile reads a rule in the Golly .rule format. The @RULE section
+// names the rule and the transitions come from either an @TABLE or an @TREE
+// section; @COLORS, if present, gives the states their colors. Other
+// sections, such as @ICONS, are ignored.
+func ReadRuleFile(r io.Reader) (Rule, error) {
+	sections := map[string][]string{}
+	var section string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "@") {
+			fields := strings.Fields(line)
+			section = fields[0]
+			sections[section] = append(sections[section], fields[1:]...)
+			continue
+		}
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line != "" && section != "" {
+			sections[section] = append(sections[section], line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	header, ok := sections["@RULE"]
+	if !ok || len(header) == 0 {
+		return nil, fmt.Errorf("rule file has no @RULE name")
+	}
+	info := &ruleFile{name: header[0]}
+
+	var rule Rule
+	var err error
+	switch {
+	case sections["@TABLE"] != nil:
+		rule, err = parseRuleTable(info, sections["@TABLE"])
+	case sections["@TREE"] != nil:
+		rule, err = parseRuleTree(info, sections["@TREE"])
+	default:
+		err = fmt.Errorf("rule file has neither @TABLE nor @TREE")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("rule %s: %v", info.name, err)
+	}
+	if err := info.parseColors(sections["@COLORS"]); err != nil {
+		return nil, fmt.Errorf("rule %s: %v", info.name, err)
+	}
+	return rule, nil
+}
+
+// parseColors reads the @COLORS section. A line of four numbers sets the
+// color of one state, and a line of six numbers sets a gradient from the
+// first color at state 1 to the second at the last state.
+func (f *ruleFile) parseColors(lines []string) error {
+	f.colors = make([]color.RGBA, f.states)
+	f.setGradient(ruleFileFirstColor, ruleFileLastColor)
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		values := make([]uint8, len(fields))
+		for i, field := range fields {
+			v, err := strconv.ParseUint(field, 10, 8)
+			if err != nil {
+				return fmt.Errorf("invalid @COLORS line '%s'", line)
+			}
+			values[i] = uint8(v)
+		}
+		switch len(values) {
+		case 4:
+			if int(values[0]) < f.states {
+				f.colors[values[0]] = color.RGBA{R: values[1], G: values[2], B: values[3], A: 255}
+			}
+		case 6:
+			f.setGradient(color.RGBA{R: values[0], G: values[1], B: values[2], A: 255},
+				color.RGBA{R: values[3], G: values[4], B: values[5], A: 255})
+		default:
+			return fmt.Errorf("invalid @COLORS line '%s'", line)
+		}
+	}
+	return nil
+}
+
+// setGradient colors states 1 to the last one along a gradient.
+func (f *ruleFile) setGradient(first, last color.RGBA) {
+	for state := 1; state < f.states; state++ {
+		t := 0.0
+		if f.states > 2 {
+			t = float64(state-1) / float64(f.states-2)
+		}
+		f.colors[state] = gradient([]color.RGBA{first, last}, t)
+	}
+}
+
+// findRuleFile loads the rule file for a rule name from RuleDirs, or from
+// the path itself if the name ends in ".rule". It reports false if there is
+// no such file.
+func findRuleFile(name string) (Rule, bool, error) {
+	if strings.HasSuffix(name, ".rule") {
+		rule, err := LoadRuleFile(name)
+		return rule, true, err
+	}
+	if strings.ContainsAny(name, `/\`) {
+		return nil, false, nil
+	}
+	for _, dir := range RuleDirs {
+		path := filepath.Join(dir, name+".rule")
+		if _, err := os.Stat(path); err == nil {
+			rule, err := LoadRuleFile(path)
+			return rule, true, err
+		}
+	}
+	return nil, false, nil
+}
@@ -0,0 +1,245 @@
+package engine
+
+import (
+	"image/color"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// readRule parses the text of a .rule file.
+func readRule(t *testing.T, text string) Rule {
+	t.Helper()
+	rule, err := ReadRuleFile(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rule
+}
+
+// randomCells returns a board of random states of the rule. The same seed
+// always produces the same board.
+func randomCells(rule Rule, width, height int, seed uint64) [][]uint8 {
+	rng := rand.New(rand.NewPCG(seed, seed))
+	cells := makeGrid[uint8](width, height)
+	for y := range cells {
+		for x := range cells[y] {
+			cells[y][x] = uint8(rng.IntN(rule.States()))
+		}
+	}
+	return cells
+}
+
+// assertSameEvolution runs two rules from the same board and fails at the
+// first generation in which any cell differs.
+func assertSameEvolution(t *testing.T, want, got Rule, cells [][]uint8, generations int) {
+	t.Helper()
+	width, height := len(cells[0]), len(cells)
+	universes := [2]*Universe{NewUniverse(width, height), NewUniverse(width, height)}
+	for i, rule := range [2]Rule{want, got} {
+		universes[i].SetRule(rule)
+		board := makeGrid[uint8](width, height)
+		for y := range cells {
+			copy(board[y], cells[y])
+		}
+		universes[i].Load(board, makeGrid[color.RGBA](width, height))
+	}
+	for gen := 1; gen <= generations; gen++ {
+		universes[0].Step()
+		universes[1].Step()
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if w, g := universes[0].State(x, y), universes[1].State(x, y); w != g {
+					t.Fatalf("generation %d: cell %d, %d is %d under %s, want %d under %s", gen, x, y, g, got, w, want)
+				}
+			}
+		}
+	}
+}
+
+// parityTable and parityTree are the same rule, in which a cell is alive
+// when an odd number of its von Neumann neighbors are, as an @TABLE and as
+// an @TREE.
+const (
+	parityTable = `@RULE ParityTable
+@TABLE
+n_states:2
+neighborhood:vonNeumann
+symmetries:permute
+var a={0,1}
+a,0,0,0,0,0
+a,1,0,0,0,1
+a,1,1,0,0,0
+a,1,1,1,0,1
+a,1,1,1,1,0
+`
+	parityTree = `@RULE ParityTree
+@TREE
+num_states=2
+num_neighbors=4
+num_nodes=9
+1 0 0
+1 1 1
+2 0 1
+2 1 0
+3 2 3
+3 3 2
+4 4 5
+4 5 4
+5 6 7
+`
+)
+
+// lifeTable is Conway's Game of Life as an @TABLE.
+const lifeTable = `@RULE LifeTable
+@TABLE
+n_states:2
+neighborhood:Moore
+symmetries:permute
+var a={0,1}
+var b={0,1}
+var c={0,1}
+var d={0,1}
+var e={0,1}
+var f={0,1}
+var g={0,1}
+var h={0,1}
+0,1,1,1,0,0,0,0,0,1
+1,1,1,0,0,0,0,0,0,1
+1,1,1,1,0,0,0,0,0,1
+1,a,b,c,d,e,f,g,h,0
+`
+
+func TestRuleFileEvolution(t *testing.T) {
+	tests := []struct {
+		name      string
+		want, got Rule
+	}{
+		{"LifeTable", Life, readRule(t, lifeTable)},
+		{"ParityTree", readRule(t, parityTable), readRule(t, parityTree)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertSameEvolution(t, tt.want, tt.got, randomCells(tt.want, 64, 48, 1), 100)
+		})
+	}
+}
+func TestTableSymmetries(t *testing.T) {
+	tests := []struct {
+		neighborhood string
+		symmetry     string
+		transitions  int   // Distinct arrangements of neighbors 1, 2, 3...
+		matches      []int // An arrangement the symmetry allows
+		misses       []int // One it does not, if any
+	}{
+		{"Moore", "none", 1, []int{1, 2, 3, 4, 5, 6, 7, 8}, []int{3, 4, 5, 6, 7, 8, 1, 2}},
+		{"Moore", "reflect_horizontal", 2, []int{1, 8, 7, 6, 5, 4, 3, 2}, []int{3, 4, 5, 6, 7, 8, 1, 2}},
+		{"Moore", "rotate4", 4, []int{3, 4, 5, 6, 7, 8, 1, 2}, []int{2, 3, 4, 5, 6, 7, 8, 1}},
+		{"Moore", "rotate4reflect", 8, []int{3, 2, 1, 8, 7, 6, 5, 4}, []int{2, 3, 4, 5, 6, 7, 8, 1}},
+		{"Moore", "rotate8", 8, []int{2, 3, 4, 5, 6, 7, 8, 1}, []int{1, 8, 7, 6, 5, 4, 3, 2}},
+		{"Moore", "rotate8reflect", 16, []int{2, 1, 8, 7, 6, 5, 4, 3}, []int{1, 3, 2, 4, 5, 6, 7, 8}},
+		{"Moore", "permute", 40320, []int{1, 3, 2, 4, 5, 6, 7, 8}, nil},
+		{"vonNeumann", "rotate4", 4, []int{2, 3, 4, 1}, []int{1, 4, 3, 2}},
+		{"vonNeumann", "rotate4reflect", 8, []int{1, 4, 3, 2}, []int{2, 1, 3, 4}},
+		{"vonNeumann", "permute", 24, []int{2, 1, 3, 4}, nil},
+		{"hexagonal", "rotate2", 2, []int{4, 5, 6, 1, 2, 3}, []int{3, 4, 5, 6, 1, 2}},
+		{"hexagonal", "rotate3", 3, []int{3, 4, 5, 6, 1, 2}, []int{2, 3, 4, 5, 6, 1}},
+		{"hexagonal", "rotate6", 6, []int{2, 3, 4, 5, 6, 1}, []int{1, 6, 5, 4, 3, 2}},
+		{"hexagonal", "rotate6reflect", 12, []int{1, 6, 5, 4, 3, 2}, []int{1, 3, 2, 4, 5, 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.neighborhood+"/"+tt.symmetry, func(t *testing.T) {
+			k := len(tt.matches)
+			elements := []string{"0"}
+			for state := 1; state <= k; state++ {
+				elements = append(elements, strconv.Itoa(state))
+			}
+			rule := readRule(t, "@RULE Symmetry\n@TABLE\nn_states:10\nneighborhood:"+tt.neighborhood+
+				"\nsymmetries:"+tt.symmetry+"\n"+strings.Join(append(elements, "9"), ",")+"\n").(*TableRule)
+			if got := len(rule.outputs); got != tt.transitions {
+				t.Errorf("%d transitions, want %d", got, tt.transitions)
+			}
+			if got := nextAmong(rule, tt.matches); got != 9 {
+				t.Errorf("arrangement %v gives %d, want 9", tt.matches, got)
+			}
+			if tt.misses != nil {
+				if got := nextAmong(rule, tt.misses); got != 0 {
+					t.Errorf("arrangement %v gives %d, want 0", tt.misses, got)
+				}
+			}
+		})
+	}
+}
+
+// nextAmong returns the next state of a dead cell with its neighbors in the
+// given states, listed in the order of the transitions of the rule.
+func nextAmong(rule *TableRule, neighbors []int) uint8 {
+	cells := makeGrid[uint8](3, 3)
+	for i, o := range rule.order {
+		cells[1+o.Y][1+o.X] = uint8(neighbors[i])
+	}
+	return rule.Next(cells, 1, 1)
+}
+
+func TestRuleFileErrors(t *testing.T) {
+	table := "@RULE Bad\n@TABLE\nn_states:3\nneighborhood:vonNeumann\n"
+	tree := "@RULE Bad\n@TREE\nnum_states=2\nnum_neighbors=4\n"
+	tests := []struct {
+		name, text, want string
+	}{
+		{"no name", "@TABLE\nn_states:2\n", "no @RULE name"},
+		{"no section", "@RULE Bad\n", "neither @TABLE nor @TREE"},
+		{"unknown variable", table + "0,x,0,0,0,1\n", "unknown variable 'x'"},
+		{"unnamed variable", table + "var ={0,1}\n", "invalid variable"},
+		{"unbound output", table + "var a={0,1}\n0,1,0,0,0,a\n", "output variable 'a' is not bound"},
+		{"several outputs", table + "0,0,0,0,0,{1,2}\n", "output must be a single state"},
+		{"too few elements", table + "0,1,0,1\n", "expected 6 elements, found 4"},
+		{"too many elements", table + "0,1,0,1,0,0,1\n", "expected 6 elements, found 7"},
+		{"state out of range", table + "0,3,0,0,0,1\n", "state 3 out of range"},
+		{"variable out of range", table + "var a={0,5}\n", "state 5 out of range"},
+		{"output out of range", table + "0,1,0,0,0,4\n", "state 4 out of range"},
+		{"too many states", "@RULE Bad\n@TABLE\nn_states:300\n", "n_states must be between 2 and 256"},
+		{"unknown neighborhood", "@RULE Bad\n@TABLE\nn_states:2\nneighborhood:Margolus\n", "unsupported neighborhood"},
+		{"unknown symmetry", table + "symmetries:mirror\n0,1,0,0,0,1\n", "unsupported symmetry 'mirror'"},
+		{"uneven rotation", table + "symmetries:rotate3\n0,1,0,0,0,1\n", "unsupported symmetry 'rotate3'"},
+		{"transition first", "@RULE Bad\n@TABLE\n0,1,0,0,0,1\n", "must come before"},
+		{"tree node arity", tree + "1 0 1 1\n", "node 0 has 4 fields, expected 3"},
+		{"tree state out of range", tree + "1 0 2\n", "leads to state 2, which is out of range"},
+		{"tree child level", tree + "1 0 1\n3 0 0\n", "node 1 has an invalid child 0"},
+		{"tree neighbors", "@RULE Bad\n@TREE\nnum_states=2\nnum_neighbors=6\n", "num_neighbors must be 4 or 8"},
+		{"tree node count", tree + "num_nodes=3\n1 0 0\n1 1 1\n", "num_nodes is 3 but the tree has 2 nodes"},
+		{"tree root", tree + "1 0 1\n", "the root must be at level 5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadRuleFile(strings.NewReader(tt.text))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleFileColors(t *testing.T) {
+	header := "@RULE Colors\n@TABLE\nn_states:4\nneighborhood:vonNeumann\n"
+	tests := []struct {
+		name   string
+		colors string
+		want   [4]color.RGBA
+	}{
+		{"default gradient", "", [4]color.RGBA{{}, {255, 0, 0, 255}, {255, 127, 0, 255}, {255, 255, 0, 255}}},
+		{"single states", "@COLORS\n1 0 0 255\n3 9 9 9\n", [4]color.RGBA{{}, {0, 0, 255, 255}, {255, 127, 0, 255}, {9, 9, 9, 255}}},
+		{"gradient", "@COLORS\n0 0 0 0 0 200\n", [4]color.RGBA{{}, {0, 0, 0, 255}, {0, 0, 100, 255}, {0, 0, 200, 255}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := readRule(t, header+tt.colors).(stateColorer)
+			for state := 1; state < len(tt.want); state++ {
+				if got := rule.stateColor(uint8(state)); got != tt.want[state] {
+					t.Errorf("state %d colored %v, want %v", state, got, tt.want[state])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,396 @@
+package engine
+
+import (
+	"fmt"
+	"image"
+	"math/bits"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// tableNeighborhoods lists the neighborhoods an @TABLE can use, with the
+// order its transitions list the neighbors in, clockwise from north.
+var tableNeighborhoods = map[string]struct {
+	neighborhood *Neighborhood
+	order        []image.Point
+}{
+	"moore": {Moore, []image.Point{
+		{0, -1}, {1, -1}, {1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1},
+	}},
+	"vonneumann": {VonNeumann, []image.Point{
+		{0, -1}, {1, 0}, {0, 1}, {-1, 0},
+	}},
+	"hexagonal": {Hexagonal, []image.Point{
+		{0, -1}, {1, 0}, {1, 1}, {0, 1}, {-1, 0}, {-1, -1},
+	}},
+}
+
+// stateSet is a set of cell states.
+type stateSet [4]uint64
+
+func (s *stateSet) add(state int)     { s[state/64] |= 1 << (state % 64) }
+func (s stateSet) has(state int) bool { return s[state/64]&(1<<(state%64)) != 0 }
+func (s stateSet) count() int {
+	return bits.OnesCount64(s[0]) + bits.OnesCount64(s[1]) + bits.OnesCount64(s[2]) + bits.OnesCount64(s[3])
+}
+func (s stateSet) union(t stateSet) stateSet {
+	return stateSet{s[0] | t[0], s[1] | t[1], s[2] | t[2], s[3] | t[3]}
+}
+
+// states lists the states in the set in increasing order.
+func (s stateSet) states() []int {
+	var states []int
+	for state := 0; state < 256; state++ {
+		if s.has(state) {
+			states = append(states, state)
+		}
+	}
+	return states
+}
+
+// tableTransition is one line of an @TABLE after variables have been bound
+// and symmetries applied: the states allowed for the cell itself and each of
+// its neighbors, and the state the cell takes if they all match.
+type tableTransition struct {
+	inputs []stateSet
+	output uint8
+}
+
+// TableRule is a rule defined by the @TABLE of a Golly .rule file: a list of
+// transitions, of which the first one that matches a cell and its neighbors
+// gives its next state. Cells that no transition matches keep their state.
+type TableRule struct {
+	*ruleFile
+	neighborhood *Neighborhood
+	order        []image.Point // Offsets of the neighbors in the order of the transitions
+	words        int           // Number of 64-bit words in a set of transitions
+	// matches holds, for every position (the cell, then each neighbor) and
+	// state, the set of transitions that allow that state there
+	matches []uint64
+	outputs []uint8
+}
+
+// parseRuleTable parses the lines of an @TABLE section.
+func parseRuleTable(info *ruleFile, lines []string) (*TableRule, error) {
+	t := &TableRule{ruleFile: info}
+	symmetry := "none"
+	vars := map[string]stateSet{}
+	var transitions []tableTransition
+	seen := map[string]bool{}
+	for _, line := range lines {
+		if key, value, ok := strings.Cut(line, ":"); ok && !strings.Contains(key, ",") {
+			value = strings.TrimSpace(value)
+			switch strings.TrimSpace(key) {
+			case "n_states":
+				states, err := strconv.Atoi(value)
+				if err != nil || states < 2 || states > 256 {
+					return nil, fmt.Errorf("n_states must be between 2 and 256")
+				}
+				t.states = states
+			case "neighborhood":
+				n, ok := tableNeighborhoods[strings.ToLower(value)]
+				if !ok {
+					return nil, fmt.Errorf("unsupported neighborhood '%s'", value)
+				}
+				t.neighborhood, t.order = n.neighborhood, n.order
+			case "symmetries":
+				symmetry = value
+			default:
+				return nil, fmt.Errorf("unknown setting '%s'", key)
+			}
+			continue
+		}
+		if t.states == 0 || t.neighborhood == nil {
+			return nil, fmt.Errorf("n_states and neighborhood must come before variables and transitions")
+		}
+
+		if rest, ok := strings.CutPrefix(line, "var "); ok {
+			name, value, ok := strings.Cut(rest, "=")
+			name = strings.TrimSpace(name)
+			if !ok || name == "" {
+				return nil, fmt.Errorf("invalid variable '%s'", line)
+			}
+			set, err := t.parseSet(strings.TrimSpace(value), vars)
+			if err != nil {
+				return nil, err
+			}
+			vars[name] = set
+			continue
+		}
+
+		expanded, err := t.parseTransition(line, vars, symmetry)
+		if err != nil {
+			return nil, fmt.Errorf("transition '%s': %v", line, err)
+		}
+		for _, tr := range expanded {
+			key := fmt.Sprint(tr.inputs)
+			if !seen[key] {
+				seen[key] = true
+				transitions = append(transitions, tr)
+			}
+		}
+	}
+	if t.states == 0 || t.neighborhood == nil {
+		return nil, fmt.Errorf("n_states and neighborhood are required")
+	}
+	t.compile(transitions)
+	return t, nil
+}
+
+// parseSet parses a state, a variable or a list of them in braces.
+func (t *TableRule) parseSet(s string, vars map[string]stateSet) (stateSet, error) {
+	var set stateSet
+	if inner, ok := strings.CutPrefix(s, "{"); ok {
+		inner, ok = strings.CutSuffix(inner, "}")
+		if !ok {
+			return set, fmt.Errorf("unclosed '{' in '%s'", s)
+		}
+		for _, item := range strings.Split(inner, ",") {
+			sub, err := t.parseSet(strings.TrimSpace(item), vars)
+			if err != nil {
+				return set, err
+			}
+			set = set.union(sub)
+		}
+		return set, nil
+	}
+	if v, ok := vars[s]; ok {
+		return v, nil
+	}
+	state, err := strconv.Atoi(s)
+	if err != nil {
+		return set, fmt.Errorf("unknown variable '%s'", s)
+	}
+	if state < 0 || state >= t.states {
+		return set, fmt.Errorf("state %d out of range", state)
+	}
+	set.add(state)
+	return set, nil
+}
+
+// splitTransition splits a transition into its elements. Elements are
+// separated by commas, except in the compact form of rules with at most ten
+// states, where every character is an element.
+func (t *TableRule) splitTransition(line string) []string {
+	if !strings.ContainsAny(line, ",{") && t.states <= 10 {
+		return strings.Split(strings.ReplaceAll(line, " ", ""), "")
+	}
+	var elements []string
+	depth, start := 0, 0
+	for i, ch := range line {
+		switch {
+		case ch == '{':
+			depth++
+		case ch == '}':
+			depth--
+		case ch == ',' && depth == 0:
+			elements = append(elements, strings.TrimSpace(line[start:i]))
+			start = i + 1
+		}
+	}
+	return append(elements, strings.TrimSpace(line[start:]))
+}
+
+// parseTransition parses a transition and expands it into concrete ones.
+// Like Golly, it treats a variable that occurs more than once as bound: all
+// its occurrences take the same state, and the output may name it to copy
+// that state.
+func (t *TableRule) parseTransition(line string, vars map[string]stateSet, symmetry string) ([]tableTransition, error) {
+	elements := t.splitTransition(line)
+	if want := len(t.order) + 2; len(elements) != want {
+		return nil, fmt.Errorf("expected %d elements, found %d", want, len(elements))
+	}
+	occurrences := map[string]int{}
+	for _, e := range elements {
+		if _, ok := vars[e]; ok {
+			occurrences[e]++
+		}
+	}
+	var bound []string
+	for _, e := range elements {
+		if occurrences[e] > 1 && !slices.Contains(bound, e) {
+			bound = append(bound, e)
+		}
+	}
+	inputs, output := elements[:len(elements)-1], elements[len(elements)-1]
+	if _, isVar := vars[output]; isVar && occurrences[output] < 2 {
+		return nil, fmt.Errorf("output variable '%s' is not bound to an input", output)
+	}
+
+	var expanded []tableTransition
+	values := map[string]int{}
+	var bind func(i int) error
+	bind = func(i int) error {
+		if i < len(bound) {
+			for _, state := range vars[bound[i]].states() {
+				values[bound[i]] = state
+				if err := bind(i + 1); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		sets := make([]stateSet, len(inputs))
+		for j, e := range inputs {
+			if state, ok := values[e]; ok {
+				sets[j] = stateSet{}
+				sets[j].add(state)
+				continue
+			}
+			set, err := t.parseSet(e, vars)
+			if err != nil {
+				return err
+			}
+			sets[j] = set
+		}
+		out, ok := values[output]
+		if !ok {
+			set, err := t.parseSet(output, vars)
+			if err != nil {
+				return err
+			}
+			if set.count() != 1 {
+				return fmt.Errorf("output must be a single state")
+			}
+			out = set.states()[0]
+		}
+
+		variants, err := t.symmetries(sets[1:], symmetry)
+		if err != nil {
+			return err
+		}
+		for _, neighbors := range variants {
+			expanded = append(expanded, tableTransition{
+				inputs: append([]stateSet{sets[0]}, neighbors...),
+				output: uint8(out),
+			})
+		}
+		return nil
+	}
+	if err := bind(0); err != nil {
+		return nil, err
+	}
+	return expanded, nil
+}
+
+// symmetries returns the distinct rearrangements of the neighbors under the
+// named symmetry: rotateN turns the neighborhood in N steps, a "reflect"
+// suffix adds its mirror images, reflect_horizontal only mirrors it, and
+// permute allows the neighbors in any order.
+func (t *TableRule) symmetries(neighbors []stateSet, symmetry string) ([][]stateSet, error) {
+	if symmetry == "permute" {
+		return permutations(neighbors), nil
+	}
+
+	k := len(neighbors)
+	rotations, reflect := 1, false
+	switch {
+	case symmetry == "none":
+	case symmetry == "reflect_horizontal":
+		reflect = true
+	case strings.HasPrefix(symmetry, "rotate"):
+		spec, hasReflect := strings.CutSuffix(strings.TrimPrefix(symmetry, "rotate"), "reflect")
+		n, err := strconv.Atoi(spec)
+		if err != nil || n < 1 || k%n != 0 {
+			return nil, fmt.Errorf("unsupported symmetry '%s'", symmetry)
+		}
+		rotations, reflect = n, hasReflect
+	default:
+		return nil, fmt.Errorf("unsupported symmetry '%s'", symmetry)
+	}
+
+	var variants [][]stateSet
+	seen := map[string]bool{}
+	for r := 0; r < rotations; r++ {
+		for _, mirrored := range []bool{false, true} {
+			if mirrored && !reflect {
+				continue
+			}
+			variant := make([]stateSet, k)
+			for i := range variant {
+				src := i
+				if mirrored {
+					src = (k - i) % k
+				}
+				variant[i] = neighbors[(src+r*k/rotations)%k]
+			}
+			if key := fmt.Sprint(variant); !seen[key] {
+				seen[key] = true
+				variants = append(variants, variant)
+			}
+		}
+	}
+	return variants, nil
+}
+
+// permutations returns every distinct ordering of the sets.
+func permutations(sets []stateSet) [][]stateSet {
+	var result [][]stateSet
+	used := make([]bool, len(sets))
+	current := make([]stateSet, 0, len(sets))
+	var permute func()
+	permute = func() {
+		if len(current) == len(sets) {
+			result = append(result, append([]stateSet(nil), current...))
+			return
+		}
+		tried := map[stateSet]bool{}
+		for i, s := range sets {
+			if used[i] || tried[s] {
+				continue
+			}
+			tried[s] = true
+			used[i] = true
+			current = append(current, s)
+			permute()
+			current = current[:len(current)-1]
+			used[i] = false
+		}
+	}
+	permute()
+	return result
+}
+
+// compile builds the lookup tables that find the first matching transition
+// with a few bitwise ANDs per position.
+func (t *TableRule) compile(transitions []tableTransition) {
+	positions := len(t.order) + 1
+	t.words = max((len(transitions)+63)/64, 1)
+	t.matches = make([]uint64, positions*t.states*t.words)
+	t.outputs = make([]uint8, len(transitions))
+	for i, tr := range transitions {
+		t.outputs[i] = tr.output
+		for pos, set := range tr.inputs {
+			for state := 0; state < t.states; state++ {
+				if set.has(state) {
+					t.matches[(pos*t.states+state)*t.words+i/64] |= 1 << (i % 64)
+				}
+			}
+		}
+	}
+}
+
+func (t *TableRule) Neighborhood() *Neighborhood { return t.neighborhood }
+
+func (t *TableRule) Next(cells [][]uint8, x, y int) uint8 {
+	height, width := len(cells), len(cells[0])
+	var states [9]int
+	states[0] = int(cells[y][x])
+	for i, o := range t.order {
+		states[i+1] = int(cells[wrap(y+o.Y, height)][wrap(x+o.X, width)])
+	}
+
+	positions := len(t.order) + 1
+	for w := 0; w < t.words; w++ {
+		match := ^uint64(0)
+		for pos := 0; pos < positions && match != 0; pos++ {
+			match &= t.matches[(pos*t.states+states[pos])*t.words+w]
+		}
+		if match != 0 {
+			return t.outputs[w*64+bits.TrailingZeros64(match)]
+		}
+	}
+	return cells[y][x]
+}
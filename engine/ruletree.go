@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+)
+
+// treeOrders lists, for the neighbor counts an @TREE can have, the cells in
+// the order the tree branches on them, ending with the cell itself.
+var treeOrders = map[int]struct {
+	neighborhood *Neighborhood
+	order        []image.Point
+}{
+	4: {VonNeumann, []image.Point{{0, -1}, {-1, 0}, {1, 0}, {0, 1}, {0, 0}}},
+	8: {Moore, []image.Point{
+		{-1, -1}, {1, -1}, {-1, 1}, {1, 1}, {0, -1}, {-1, 0}, {1, 0}, {0, 1}, {0, 0},
+	}},
+}
+
+// TreeRule is a rule defined by the @TREE of a Golly .rule file: a decision
+// tree that branches on the state of one cell of the neighborhood per level,
+// with the next state at its leaves.
+type TreeRule struct {
+	*ruleFile
+	neighborhood *Neighborhood
+	order        []image.Point
+	// nodes holds the children of every node, states wide. Children of
+	// level 1 nodes are next states, the others are indices into nodes.
+	nodes []int32
+	root  int32
+}
+
+// parseRuleTree parses the lines of an @TREE section: the num_states,
+// num_neighbors and num_nodes settings followed by one node per line, made
+// of its level and its children. Nodes only refer to nodes before them, and
+// the last one is the root.
+func parseRuleTree(info *ruleFile, lines []string) (*TreeRule, error) {
+	t := &TreeRule{ruleFile: info}
+	numNodes := -1
+	var levels []int
+	for _, line := range lines {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting '%s'", line)
+			}
+			switch strings.TrimSpace(key) {
+			case "num_states":
+				if n < 2 || n > 256 {
+					return nil, fmt.Errorf("num_states must be between 2 and 256")
+				}
+				t.states = n
+			case "num_neighbors":
+				order, ok := treeOrders[n]
+				if !ok {
+					return nil, fmt.Errorf("num_neighbors must be 4 or 8")
+				}
+				t.neighborhood, t.order = order.neighborhood, order.order
+			case "num_nodes":
+				numNodes = n
+			default:
+				return nil, fmt.Errorf("unknown setting '%s'", key)
+			}
+			continue
+		}
+		if t.states == 0 || t.neighborhood == nil {
+			return nil, fmt.Errorf("num_states and num_neighbors must come before the nodes")
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != t.states+1 {
+			return nil, fmt.Errorf("node %d has %d fields, expected %d", len(levels), len(fields), t.states+1)
+		}
+		level, err := strconv.Atoi(fields[0])
+		if err != nil || level < 1 || level > len(t.order) {
+			return nil, fmt.Errorf("node %d has an invalid level", len(levels))
+		}
+		for _, f := range fields[1:] {
+			child, err := strconv.Atoi(f)
+			switch {
+			case err != nil:
+				return nil, fmt.Errorf("node %d has an invalid child '%s'", len(levels), f)
+			case level == 1 && (child < 0 || child >= t.states):
+				return nil, fmt.Errorf("node %d leads to state %d, which is out of range", len(levels), child)
+			case level > 1 && (child < 0 || child >= len(levels) || levels[child] != level-1):
+				return nil, fmt.Errorf("node %d has an invalid child %d", len(levels), child)
+			}
+			t.nodes = append(t.nodes, int32(child))
+		}
+		levels = append(levels, level)
+	}
+
+	switch {
+	case len(levels) == 0:
+		return nil, fmt.Errorf("tree has no nodes")
+	case numNodes >= 0 && numNodes != len(levels):
+		return nil, fmt.Errorf("num_nodes is %d but the tree has %d nodes", numNodes, len(levels))
+	case levels[len(levels)-1] != len(t.order):
+		return nil, fmt.Errorf("the root must be at level %d", len(t.order))
+	}
+	t.root = int32(len(levels) - 1)
+	return t, nil
+}
+
+func (t *TreeRule) Neighborhood() *Neighborhood { return t.neighborhood }
+
+func (t *TreeRule) Next(cells [][]uint8, x, y int) uint8 {
+	height, width := len(cells), len(cells[0])
+	node := t.root
+	for _, o := range t.order {
+		state := cells[wrap(y+o.Y, height)][wrap(x+o.X, width)]
+		node = t.nodes[int(node)*t.states+int(state)]
+	}
+	return uint8(node)
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// handleScreenshotInput saves screenshots: F12 saves the window as it is
+// drawn, HUD and overlays included, in the next Draw, and Shift+F12 saves
+// just the board at the current cell size.
+func (g *Game) handleScreenshotInput() {
+	if g.keys.pressed(actionScreenshot) {
+		g.screenshotPending = true
+	}
+	if g.keys.pressed(actionBoardScreenshot) {
+		g.reportScreenshot(g.saveScreenshot(g.universe.Render(RenderOptions{CellSize: g.GetCellSize()})))
+	}
+}
+
+// captureScreen saves the screen if a screenshot of the window was asked
+// for. It is called at the end of Draw, once everything is on the screen.
+func (g *Game) captureScreen(screen *ebiten.Image) {
+	if !g.screenshotPending {
+		return
+	}
+	g.screenshotPending = false
+	img := image.NewRGBA(screen.Bounds())
+	screen.ReadPixels(img.Pix)
+	// Pixels the game left undrawn are transparent
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+	g.reportScreenshot(g.saveScreenshot(img))
+}
+
+// saveScreenshot writes a screenshot to a PNG file named after the pattern
+// and the time, and returns the file name.
+func (g *Game) saveScreenshot(img image.Image) (string, error) {
+	path := fmt.Sprintf("screenshot-%s-%s.png", g.name, time.Now().Format("20060102-150405"))
+	return path, WritePNG(path, img)
+}
+
+// reportScreenshot shows the outcome of saving a screenshot in the HUD.
+func (g *Game) reportScreenshot(path string, err error) {
+	if err != nil {
+		log.Printf("Failed to save screenshot: %v", err)
+		g.statusMessage = "Screenshot failed"
+		return
+	}
+	g.statusMessage = "Saved " + path
+}
+
+// handleRecordingInput starts and stops recordings: F9 records an animated
+// GIF and Shift+F9 an animated PNG, and pressing F9 again saves it.
+func (g *Game) handleRecordingInput() {
+	gif, apng := g.keys.pressed(actionRecordGIF), g.keys.pressed(actionRecordAPNG)
+	switch {
+	case !gif && !apng:
+	case g.recorder != nil:
+		g.stopRecording()
+	default:
+		g.recorder = NewRecorder(DefaultMaxRecordingFrames, DefaultMaxRecordingBytes)
+		g.recordAPNG = apng
+		g.recordedGeneration = -1
+		g.statusMessage = "Recording, press again to stop"
+	}
+}
+
+// recordFrame adds the current generation to the recording, once per
+// generation. A recording stops and is saved by itself once it is full or
+// if the board changes size.
+func (g *Game) recordFrame() {
+	if g.recorder == nil || g.universe.Generation() == g.recordedGeneration {
+		return
+	}
+	if err := g.recorder.Capture(g.universe); err != nil {
+		log.Printf("Recording stopped: %v", err)
+		g.stopRecording()
+		g.statusMessage = fmt.Sprintf("Recording stopped, %v. %s", err, g.statusMessage)
+		return
+	}
+	g.recordedGeneration = g.universe.Generation()
+}
+
+// stopRecording saves the recording, cropped to where the cells went and
+// played at the current tick speed, to a file named after the pattern and
+// the time.
+func (g *Game) stopRecording() {
+	recorder := g.recorder
+	g.recorder = nil
+
+	g.tickSpeedMutex.Lock()
+	delay := time.Duration(g.tickInterval * float64(time.Second))
+	g.tickSpeedMutex.Unlock()
+	opts := AnimationOptions{CellSize: g.GetCellSize(), Crop: true, Delay: delay}
+
+	path := fmt.Sprintf("recording-%s-%s", g.name, time.Now().Format("20060102-150405"))
+	write := recorder.WriteGIF
+	if g.recordAPNG {
+		path += ".png"
+		write = recorder.WriteAPNG
+	} else {
+		path += ".gif"
+	}
+	err := writeFile(path, func(file *os.File) error { return write(file, opts) })
+	if err != nil {
+		log.Printf("Failed to save recording: %v", err)
+		g.statusMessage = "Recording failed"
+		return
+	}
+	g.statusMessage = fmt.Sprintf("Saved %s, %d frames", path, recorder.Len())
+}
@@ -0,0 +1,233 @@
+package engine
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"math/rand/v2"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/jared-wallace/gol/patterns"
+)
+
+// randomFillDensity is the fraction of cells set alive when randomizing a selection
+const randomFillDensity = 0.5
+
+var (
+	selectionFill    = color.RGBA{R: 60, G: 120, B: 255, A: 60}
+	selectionBorder  = color.RGBA{R: 90, G: 150, B: 255, A: 255}
+	pastePreviewCell = color.RGBA{R: 255, G: 200, B: 60, A: 160}
+)
+
+// selection is a rectangle of cells between an anchor and the opposite corner.
+type selection struct {
+	anchorX, anchorY int
+	cornerX, cornerY int
+}
+
+// rect returns the top-left corner and size of the selection.
+func (s *selection) rect() (x, y, w, h int) {
+	x, y = min(s.anchorX, s.cornerX), min(s.anchorY, s.cornerY)
+	w = max(s.anchorX, s.cornerX) - x + 1
+	h = max(s.anchorY, s.cornerY) - y + 1
+	return x, y, w, h
+}
+
+// cellAt converts a screen position to cell coordinates, clamped to the board.
+func (g *Game) cellAt(mx, my int) (int, int) {
+	g.cellSizeMutex.Lock()
+	cellSize := g.cellSize
+	g.cellSizeMutex.Unlock()
+
+	mx = min(max(mx, 0), g.width*cellSize-1)
+	my = min(max(my, 0), g.height*cellSize-1)
+	x, y, _ := g.screenToCell(mx, my, cellSize)
+	return x, y
+}
+
+// handleSelectionInput manages the keyboard side of selecting, copying,
+// cutting, pasting and clearing, filling or randomizing the selection.
+func (g *Game) handleSelectionInput() {
+	// Handle input: copy and cut the selection
+	if g.keys.pressed(actionCopy) {
+		g.copySelection()
+	}
+	if g.keys.pressed(actionCut) {
+		if g.copySelection() {
+			g.fillSelection(func() bool { return false })
+		}
+	}
+
+	// Handle input: start pasting once the system clipboard is read
+	if g.keys.pressed(actionPaste) {
+		g.systemClipboard.read()
+	}
+	g.finishPaste()
+
+	// Handle input: clear, fill or randomize the selection
+	if g.keys.pressed(actionClearSelection) {
+		g.fillSelection(func() bool { return false })
+	}
+	if g.keys.pressed(actionFillSelection) {
+		g.fillSelection(func() bool { return true })
+	}
+	if g.keys.pressed(actionRandomizeSelection) && g.pasting == nil {
+		g.fillSelection(func() bool { return rand.Float64() < randomFillDensity })
+	}
+
+	// Handle input: rotate and flip the paste preview
+	if g.pasting != nil {
+		if g.keys.pressed(actionRotatePaste) {
+			g.pasteBase.Rotate()
+			g.updatePastePreview()
+		}
+		if g.keys.pressed(actionFlipHorizontal) {
+			g.pasteBase.FlipHorizontal()
+			g.updatePastePreview()
+		}
+		if g.keys.pressed(actionFlipVertical) {
+			g.pasteBase.FlipVertical()
+			g.updatePastePreview()
+		}
+	}
+
+	// Handle input: pick the next library pattern for placing
+	if g.keys.pressed(actionPlacePattern) {
+		g.nextLibraryPattern()
+	}
+
+	// Handle input: change the phase of the paste preview
+	if g.keys.pressed(actionPreviousPhase) && g.pasting != nil && g.pastePhase > 0 {
+		g.pastePhase--
+		g.updatePastePreview()
+	}
+	if g.keys.pressed(actionNextPhase) && g.pasting != nil {
+		g.pastePhase++
+		g.updatePastePreview()
+	}
+}
+
+// beginPaste makes clip the floating paste preview.
+func (g *Game) beginPaste(clip *Clip) {
+	g.pasteBase = clip
+	g.pastePhase = 0
+	g.updatePastePreview()
+}
+
+// updatePastePreview recomputes the paste preview from its oriented base and phase.
+func (g *Game) updatePastePreview() {
+	g.pasting = g.pasteBase.Advance(g.universe.Rule(), g.pastePhase)
+}
+
+// nextLibraryPattern loads the next pattern from the library as the paste
+// preview, skipping the random configuration.
+func (g *Game) nextLibraryPattern() {
+	count := g.patternGenerator.GetPatternCount()
+	if count < 2 {
+		return
+	}
+	g.libraryIndex = g.libraryIndex%(count-1) + 1
+	p, err := patterns.LoadPattern(g.patternGenerator.GetPatternName(g.libraryIndex))
+	if err != nil {
+		log.Printf("Failed to load library pattern: %v", err)
+		return
+	}
+	g.beginPaste(ClipFromPattern(p))
+	g.statusMessage = fmt.Sprintf("Placing %s", p.Name)
+}
+
+// copySelection puts the selected cells on the internal clipboard and, as
+// RLE text, on the system clipboard in the background. It returns false if
+// nothing is selected.
+func (g *Game) copySelection() bool {
+	if g.selection == nil {
+		return false
+	}
+	x, y, w, h := g.selection.rect()
+	g.clipboard = g.universe.Region(x, y, w, h)
+	g.systemClipboard.write(g.clipboard.RLE(g.universe.Rule()))
+	return true
+}
+
+// finishPaste begins pasting once the system clipboard has been read: its
+// contents if they are a valid RLE pattern, or the internal clipboard
+// otherwise.
+func (g *Game) finishPaste() {
+	text, done, err := g.systemClipboard.poll()
+	if !done {
+		return
+	}
+	if err == nil {
+		if clip, err := ClipFromRLE(text); err == nil {
+			g.beginPaste(clip)
+			return
+		}
+	}
+	if g.clipboard != nil {
+		g.beginPaste(g.clipboard.Clone())
+	}
+}
+
+// stampPaste writes the paste preview onto the board as an undoable edit.
+func (g *Game) stampPaste(mx, my int) {
+	x, y := g.cellAt(mx, my)
+	g.universe.Apply(g.pasting.Changes(x, y))
+	g.pasting = nil
+}
+
+// fillSelection sets every selected cell for which alive returns true to the
+// paint state and clears the others, as a single undoable edit.
+func (g *Game) fillSelection(alive func() bool) {
+	if g.selection == nil {
+		return
+	}
+	x, y, w, h := g.selection.rect()
+	changes := make([]CellChange, 0, w*h)
+	for dy := 0; dy < h; dy++ {
+		for dx := 0; dx < w; dx++ {
+			var state uint8
+			if alive() {
+				state = g.paintState
+			}
+			changes = append(changes, CellChange{X: x + dx, Y: y + dy, State: state, Color: g.universe.BirthColor()})
+		}
+	}
+	g.universe.Apply(changes)
+}
+
+// drawSelection draws the selection rectangle and the floating paste preview.
+// On a hexagonal grid the rectangle becomes a parallelogram, which is filled
+// row by row and left without a border.
+func (g *Game) drawSelection(screen *ebiten.Image, cellSize int, hex bool) {
+	if g.selection != nil {
+		x, y, w, h := g.selection.rect()
+		if hex {
+			for row := y; row < y+h; row++ {
+				g.drawRun(screen, x, row, w, cellSize, g.rowShift(row, cellSize, hex), selectionFill)
+			}
+		} else {
+			sx, sy := float32(x*cellSize), float32(y*cellSize)
+			sw, sh := float32(w*cellSize), float32(h*cellSize)
+			vector.DrawFilledRect(screen, sx, sy, sw, sh, selectionFill, false)
+			vector.StrokeRect(screen, sx, sy, sw, sh, 1, selectionBorder, false)
+		}
+	}
+
+	if g.pasting != nil {
+		px, py := g.cellAt(ebiten.CursorPosition())
+		for dy, row := range g.pasting.Cells {
+			y := (py + dy) % g.height
+			shift := g.rowShift(y, cellSize, hex)
+			for dx, state := range row {
+				if state != 0 {
+					g.drawRun(screen, (px+dx)%g.width, y, 1, cellSize, shift, pastePreviewCell)
+				}
+			}
+		}
+		if !hex {
+			vector.StrokeRect(screen, float32(px*cellSize), float32(py*cellSize),
+				float32(g.pasting.Width*cellSize), float32(g.pasting.Height*cellSize), 1, pastePreviewCell, false)
+		}
+	}
+}
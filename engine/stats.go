@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// DefaultStatsCapacity is the number of generations of statistics a universe keeps.
+const DefaultStatsCapacity = 4096
+
+// Stats summarises a single generation of a universe.
+type Stats struct {
+	Generation int     `json:"generation"`
+	Population int     `json:"population"`
+	Births     int     `json:"births"`
+	Deaths     int     `json:"deaths"`
+	MinX       int     `json:"minX"` // Bounding box of the live cells, all -1 when the board is empty
+	MinY       int     `json:"minY"`
+	MaxX       int     `json:"maxX"`
+	MaxY       int     `json:"maxY"`
+	Density    float64 `json:"density"` // Population divided by board area
+}
+
+// tally accumulates statistics over part of the board.
+type tally struct {
+	population, births, deaths int
+	minX, minY, maxX, maxY     int
+}
+
+// newTally returns an empty tally with an empty bounding box.
+func newTally() tally {
+	return tally{minX: -1, minY: -1, maxX: -1, maxY: -1}
+}
+
+// addAlive records a live cell at (x, y).
+func (t *tally) addAlive(x, y int) {
+	t.population++
+	if t.minX < 0 || x < t.minX {
+		t.minX = x
+	}
+	if t.minY < 0 || y < t.minY {
+		t.minY = y
+	}
+	if x > t.maxX {
+		t.maxX = x
+	}
+	if y > t.maxY {
+		t.maxY = y
+	}
+}
+
+// merge folds another tally into t.
+func (t *tally) merge(o tally) {
+	t.population += o.population
+	t.births += o.births
+	t.deaths += o.deaths
+	if o.population == 0 {
+		return
+	}
+	if t.minX < 0 || o.minX < t.minX {
+		t.minX = o.minX
+	}
+	if t.minY < 0 || o.minY < t.minY {
+		t.minY = o.minY
+	}
+	if o.maxX > t.maxX {
+		t.maxX = o.maxX
+	}
+	if o.maxY > t.maxY {
+		t.maxY = o.maxY
+	}
+}
+
+// stats converts the tally into the Stats for a generation on a board of the given area.
+func (t tally) stats(generation, area int) Stats {
+	s := Stats{
+		Generation: generation,
+		Population: t.population,
+		Births:     t.births,
+		Deaths:     t.deaths,
+		MinX:       t.minX,
+		MinY:       t.minY,
+		MaxX:       t.maxX,
+		MaxY:       t.maxY,
+	}
+	if area > 0 {
+		s.Density = float64(t.population) / float64(area)
+	}
+	return s
+}
+
+// StatsHistory is a fixed size ring buffer of per-generation statistics.
+// Once full, recording a new generation overwrites the oldest one.
+type StatsHistory struct {
+	buf   []Stats
+	start int // Index of the oldest entry
+	count int
+}
+
+// NewStatsHistory creates a history that keeps the latest capacity generations.
+func NewStatsHistory(capacity int) *StatsHistory {
+	return &StatsHistory{buf: make([]Stats, capacity)}
+}
+
+// Add records the statistics of a generation.
+func (h *StatsHistory) Add(s Stats) {
+	if len(h.buf) == 0 {
+		return
+	}
+	if h.count < len(h.buf) {
+		h.buf[(h.start+h.count)%len(h.buf)] = s
+		h.count++
+		return
+	}
+	h.buf[h.start] = s
+	h.start = (h.start + 1) % len(h.buf)
+}
+
+// Reset discards all recorded generations.
+func (h *StatsHistory) Reset() {
+	h.start = 0
+	h.count = 0
+}
+
+// Truncate discards every recorded generation after the given one.
+func (h *StatsHistory) Truncate(generation int) {
+	for h.count > 0 && h.At(h.count-1).Generation > generation {
+		h.count--
+	}
+}
+
+// Len returns the number of recorded generations.
+func (h *StatsHistory) Len() int {
+	return h.count
+}
+
+// At returns the i-th recorded generation, where 0 is the oldest.
+func (h *StatsHistory) At(i int) Stats {
+	return h.buf[(h.start+i)%len(h.buf)]
+}
+
+// Latest returns the most recently recorded generation, if any.
+func (h *StatsHistory) Latest() (Stats, bool) {
+	if h.count == 0 {
+		return Stats{}, false
+	}
+	return h.At(h.count - 1), true
+}
+
+// Series returns a copy of the recorded generations, oldest first.
+func (h *StatsHistory) Series() []Stats {
+	series := make([]Stats, h.count)
+	for i := range series {
+		series[i] = h.At(i)
+	}
+	return series
+}
+
+// WriteCSV writes the recorded generations as CSV with a header row.
+func (h *StatsHistory) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"generation", "population", "births", "deaths", "minX", "minY", "maxX", "maxY", "density"}); err != nil {
+		return err
+	}
+	for i := 0; i < h.count; i++ {
+		s := h.At(i)
+		record := []string{
+			strconv.Itoa(s.Generation),
+			strconv.Itoa(s.Population),
+			strconv.Itoa(s.Births),
+			strconv.Itoa(s.Deaths),
+			strconv.Itoa(s.MinX),
+			strconv.Itoa(s.MinY),
+			strconv.Itoa(s.MaxX),
+			strconv.Itoa(s.MaxY),
+			strconv.FormatFloat(s.Density, 'f', -1, 64),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the recorded generations as a JSON array.
+func (h *StatsHistory) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(h.Series())
+}
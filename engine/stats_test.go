@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// statsFor returns made-up statistics for a generation.
+func statsFor(generation int) Stats {
+	return Stats{
+		Generation: generation,
+		Population: 10 + generation,
+		Births:     generation,
+		Deaths:     2 * generation,
+		MinX:       1,
+		MinY:       2,
+		MaxX:       3 + generation,
+		MaxY:       4,
+		Density:    float64(10+generation) / 400,
+	}
+}
+
+func TestStatsCSV(t *testing.T) {
+	const header = "generation,population,births,deaths,minX,minY,maxX,maxY,density\n"
+	tests := []struct {
+		name        string
+		capacity    int
+		generations int
+		want        string
+	}{
+		{"empty", 4, 0, header},
+		{"partly full", 4, 2, header +
+			"0,10,0,0,1,2,3,4,0.025\n" +
+			"1,11,1,2,1,2,4,4,0.0275\n"},
+		{"wrapped around", 3, 5, header +
+			"2,12,2,4,1,2,5,4,0.03\n" +
+			"3,13,3,6,1,2,6,4,0.0325\n" +
+			"4,14,4,8,1,2,7,4,0.035\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewStatsHistory(tt.capacity)
+			for gen := 0; gen < tt.generations; gen++ {
+				h.Add(statsFor(gen))
+			}
+			var b strings.Builder
+			if err := h.WriteCSV(&b); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("wrote\n%s\nwant\n%s", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestStatsJSON(t *testing.T) {
+	h := NewStatsHistory(3)
+	for gen := 0; gen < 5; gen++ {
+		h.Add(statsFor(gen))
+	}
+	var b bytes.Buffer
+	if err := h.WriteJSON(&b); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []Stats
+	if err := json.Unmarshal(b.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if want := h.Series(); !reflect.DeepEqual(decoded, want) {
+		t.Errorf("decoded %+v, want %+v", decoded, want)
+	}
+	if decoded[0].Generation != 2 {
+		t.Errorf("oldest exported generation is %d, want 2", decoded[0].Generation)
+	}
+}
+
+func TestUniverseStats(t *testing.T) {
+	// A blinker turns every generation: two cells die and two are born
+	u := NewUniverse(16, 16)
+	cells := boardWith(16, 16, 5, 7, "###")
+	u.Load(cells, blankColors(cells))
+	u.Step()
+	u.Step()
+	want := []Stats{
+		{Generation: 0, Population: 3, MinX: 5, MinY: 7, MaxX: 7, MaxY: 7, Density: 3.0 / 256},
+		{Generation: 1, Population: 3, Births: 2, Deaths: 2, MinX: 6, MinY: 6, MaxX: 6, MaxY: 8, Density: 3.0 / 256},
+		{Generation: 2, Population: 3, Births: 2, Deaths: 2, MinX: 5, MinY: 7, MaxX: 7, MaxY: 7, Density: 3.0 / 256},
+	}
+	if got := u.Stats().Series(); !reflect.DeepEqual(got, want) {
+		t.Errorf("recorded %+v, want %+v", got, want)
+	}
+}
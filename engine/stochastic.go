@@ -0,0 +1,170 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StochasticRule applies another rule with chance: births and deaths the
+// base rule calls for only happen with some probability, and live cells that
+// would stay as they are may die at random. It is written as the base
+// rulestring followed by '~' and the probabilities, such as "B3/S23~b0.9" for
+// Life in which only nine in ten births happen, or "B3/S23~n0.001" for Life
+// with one in a thousand live cells dying each generation.
+type StochasticRule struct {
+	base  Rule
+	birth float64 // Chance that a cell in state 0 changes when the base rule says so
+	death float64 // Chance that a live cell changes when the base rule says so
+	noise float64 // Chance that a live cell that would stay as it is dies
+}
+
+// parseStochastic parses the probabilities after the '~' of a stochastic
+// rule: any of b for births, d for deaths and n for noise, each followed by
+// a probability and separated by further '~'.
+func parseStochastic(base Rule, spec string) (*StochasticRule, error) {
+	r := &StochasticRule{base: base, birth: 1, death: 1}
+	seen := map[byte]bool{}
+	for _, part := range strings.Split(spec, "~") {
+		if part == "" {
+			return nil, fmt.Errorf("empty probability")
+		}
+		tag := strings.ToLower(part[:1])[0]
+		if seen[tag] {
+			return nil, fmt.Errorf("'%c' given twice", tag)
+		}
+		seen[tag] = true
+		p, err := strconv.ParseFloat(part[1:], 64)
+		if err != nil || p < 0 || p > 1 {
+			return nil, fmt.Errorf("invalid probability '%s'", part)
+		}
+		switch tag {
+		case 'b':
+			r.birth = p
+		case 'd':
+			r.death = p
+		case 'n':
+			r.noise = p
+		default:
+			return nil, fmt.Errorf("unknown probability '%s'", part)
+		}
+	}
+	return r, nil
+}
+
+// String returns the base rulestring followed by the probabilities that
+// differ from a deterministic rule.
+func (r *StochasticRule) String() string {
+	s := r.base.String()
+	for _, p := range []struct {
+		tag   string
+		value float64
+		none  float64
+	}{{"b", r.birth, 1}, {"d", r.death, 1}, {"n", r.noise, 0}} {
+		if p.value != p.none {
+			s += "~" + p.tag + strconv.FormatFloat(p.value, 'g', -1, 64)
+		}
+	}
+	return s
+}
+
+func (r *StochasticRule) States() int { return r.base.States() }
+
+func (r *StochasticRule) Neighborhood() *Neighborhood { return r.base.Neighborhood() }
+
+// Next applies the base rule with the chances rolled for the first
+// generation under seed 0. Universes roll for every generation from their
+// own seed.
+func (r *StochasticRule) Next(cells [][]uint8, x, y int) uint8 {
+	roll := newDice(0, 0).roll(y*len(cells[0])+x, ruleSalt)
+	return r.perturb(cells[y][x], r.base.Next(cells, x, y), roll)
+}
+
+// perturb returns the state a cell in the given state takes when the base
+// rule says it becomes next, given a roll between 0 and 1.
+func (r *StochasticRule) perturb(state, next uint8, roll float64) uint8 {
+	switch {
+	case state == 0 && next != 0 && roll >= r.birth:
+		return 0
+	case state != 0 && next != state && roll >= r.death:
+		return state
+	case state != 0 && next == state && roll < r.noise:
+		return 0
+	}
+	return next
+}
+
+// baseRule returns the rule a stochastic rule applies with chance, or the
+// rule itself for other rules. The optional interfaces of rules are looked
+// up on it.
+func baseRule(rule Rule) Rule {
+	if r, ok := rule.(*StochasticRule); ok {
+		return r.base
+	}
+	return rule
+}
+
+// UpdateMode selects which cells are updated every generation.
+type UpdateMode int
+
+const (
+	SyncUpdate       UpdateMode = iota // Every cell, all at once
+	FractionUpdate                     // Each cell with a given chance, all at once
+	SequentialUpdate                   // As many random cells as the board has, one after the other
+)
+
+// updateModeNames maps update modes to their names.
+var updateModeNames = map[UpdateMode]string{
+	SyncUpdate:       "sync",
+	FractionUpdate:   "fraction",
+	SequentialUpdate: "sequential",
+}
+
+// String returns the name of the update mode.
+func (m UpdateMode) String() string { return updateModeNames[m] }
+
+// ParseUpdateMode returns the update mode with the given name.
+func ParseUpdateMode(name string) (UpdateMode, error) {
+	for mode, modeName := range updateModeNames {
+		if modeName == name {
+			return mode, nil
+		}
+	}
+	return SyncUpdate, fmt.Errorf("unknown update mode '%s'", name)
+}
+
+// Salts that keep the rolls made for different purposes apart.
+const (
+	ruleSalt uint64 = iota + 1
+	updateSalt
+	pickSalt
+)
+
+// dice rolls the random numbers of one generation. Every roll is a hash of
+// the seed, the generation, the cell or update it is for and its purpose,
+// so a run replays exactly from the same seed however the workers share out
+// the cells.
+type dice struct {
+	key uint64
+}
+
+// newDice returns the dice for a generation under a seed.
+func newDice(seed uint64, generation int) dice {
+	return dice{key: mix64(seed ^ mix64(uint64(generation)))}
+}
+
+// roll returns a number between 0 and 1, excluding 1, for the given index
+// and purpose.
+func (d dice) roll(index int, salt uint64) float64 {
+	h := mix64(d.key ^ mix64(uint64(index)<<2|salt))
+	return float64(h>>11) / (1 << 53)
+}
+
+// mix64 is the finalizer of the SplitMix64 generator, which spreads every
+// bit of its input over the whole output.
+func mix64(z uint64) uint64 {
+	z += 0x9e3779b97f4a7c15
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
@@ -0,0 +1,134 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// runSeeded runs a soup for 30 generations under the rule, update mode and
+// seed with the given number of workers and returns the final board.
+func runSeeded(t *testing.T, rule string, mode UpdateMode, seed uint64, workers int) [][]uint8 {
+	t.Helper()
+	r, err := ParseRule(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetWorkers(workers)
+	cells, colors := soup(96, 64, 0.35, 4)
+	u := NewUniverse(96, 64)
+	u.SetRule(r)
+	u.SetSeed(seed)
+	u.SetUpdateMode(mode, 0.5)
+	u.Load(cells, colors)
+	for i := 0; i < 30; i++ {
+		u.Step()
+	}
+	return snapshot(u)
+}
+
+func TestSeedReproducible(t *testing.T) {
+	defer SetWorkers(0)
+	tests := []struct {
+		rule string
+		mode UpdateMode
+	}{
+		{"B3/S23~b0.9~n0.01", SyncUpdate},
+		{"life", FractionUpdate},
+		{"life", SequentialUpdate},
+		{"brians-brain~d0.5", FractionUpdate},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%s", tt.rule, tt.mode), func(t *testing.T) {
+			want := runSeeded(t, tt.rule, tt.mode, 42, 1)
+			if got := runSeeded(t, tt.rule, tt.mode, 42, 5); !slices.EqualFunc(got, want, slices.Equal) {
+				t.Error("the same seed gave another board with more workers")
+			}
+			if got := runSeeded(t, tt.rule, tt.mode, 43, 1); slices.EqualFunc(got, want, slices.Equal) {
+				t.Error("another seed gave the same board")
+			}
+		})
+	}
+}
+
+func TestFractionUpdateSkipsChance(t *testing.T) {
+	// With no cell updated, not even certain noise may kill a cell
+	rule, err := ParseRule("B3/S23~n1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cells, colors := soup(64, 48, 0.35, 5)
+	want := cloneGrid(cells)
+	u := NewUniverse(64, 48)
+	u.SetRule(rule)
+	u.SetUpdateMode(FractionUpdate, 0)
+	u.Load(cells, colors)
+	for gen := 1; gen <= 5; gen++ {
+		u.Step()
+		assertBoard(t, u, gen, want)
+	}
+}
+
+func TestStochasticRoundTrip(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"B3/S23~b0.9", "B3/S23~b0.9"},
+		{"life~N0.001", "B3/S23~n0.001"},
+		{"B3/S23~n0.5~b0.25~d1", "B3/S23~b0.25~n0.5"},
+		{"bbm~d0.5", "MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14;15~d0.5"},
+		{"B3/S23~b1", "B3/S23"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := ParseRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rule.String() != tt.want {
+				t.Fatalf("written as %q, want %q", rule.String(), tt.want)
+			}
+			again, err := ParseRule(rule.String())
+			if err != nil || again.String() != tt.want {
+				t.Errorf("reparsed as %v, %v", again, err)
+			}
+		})
+	}
+}
+
+func TestStochasticErrors(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"B3/S23~", "empty probability"},
+		{"B3/S23~b0.5~b0.6", "'b' given twice"},
+		{"B3/S23~b1.5", "invalid probability 'b1.5'"},
+		{"B3/S23~b-0.1", "invalid probability 'b-0.1'"},
+		{"B3/S23~bx", "invalid probability 'bx'"},
+		{"B3/S23~x0.5", "unknown probability 'x0.5'"},
+		{"B9/S23~b0.5", "B9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			_, err := ParseRule(tt.rule)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUpdateMode(t *testing.T) {
+	for _, mode := range []UpdateMode{SyncUpdate, FractionUpdate, SequentialUpdate} {
+		got, err := ParseUpdateMode(mode.String())
+		if err != nil || got != mode {
+			t.Errorf("%s: got %v, %v", mode, got, err)
+		}
+	}
+	if _, err := ParseUpdateMode("parallel"); err == nil {
+		t.Error("unknown update mode accepted")
+	}
+}
@@ -0,0 +1,73 @@
+package engine
+
+import "image"
+
+// summingRule is implemented by rules whose neighborhoods are too large to
+// scan cell by cell. They count live neighbors through a summed-area table
+// of the board, which the universe builds before every generation.
+type summingRule interface {
+	Rule
+	nextFromSums(sums *summedArea, cells [][]uint8, x, y int) uint8
+}
+
+// summedArea is a summed-area table of the cells in state 1, over the board
+// extended by a margin of wrapped-around cells on every side. The number of
+// live cells in any rectangle within the margin of a cell then takes four
+// lookups, however large the rectangle.
+type summedArea struct {
+	margin int
+	stride int     // Width of the extended board plus one
+	sums   []int32 // sums[j*stride+i] counts the cells above and left of (i, j) on the extended board
+}
+
+// summedAreaBlock is the number of rows or columns each worker job covers
+// while the table is built.
+const summedAreaBlock = 64
+
+// build fills the table for the given board and margin. Rows are summed in
+// parallel first, and then columns.
+func (s *summedArea) build(cells [][]uint8, margin int) {
+	height, width := len(cells), len(cells[0])
+	extWidth, extHeight := width+2*margin, height+2*margin
+	s.margin = margin
+	s.stride = extWidth + 1
+	if n := s.stride * (extHeight + 1); len(s.sums) != n {
+		s.sums = make([]int32, n)
+	}
+
+	pool := getPool()
+	blocks := func(n int) int { return (n + summedAreaBlock - 1) / summedAreaBlock }
+	pool.run(blocks(extHeight), func(b int, _ *worker) {
+		for j := b * summedAreaBlock; j < min((b+1)*summedAreaBlock, extHeight); j++ {
+			row := cells[wrap(j-margin, height)]
+			out := s.sums[(j+1)*s.stride:]
+			var sum int32
+			for i, x := 0, wrap(-margin, width); i < extWidth; i++ {
+				if row[x] == 1 {
+					sum++
+				}
+				out[i+1] = sum
+				if x++; x == width {
+					x = 0
+				}
+			}
+		}
+	})
+	pool.run(blocks(extWidth), func(b int, _ *worker) {
+		i0, i1 := b*summedAreaBlock+1, min((b+1)*summedAreaBlock, extWidth)+1
+		for j := 1; j <= extHeight; j++ {
+			above, row := s.sums[(j-1)*s.stride:], s.sums[j*s.stride:]
+			for i := i0; i < i1; i++ {
+				row[i] += above[i]
+			}
+		}
+	})
+}
+
+// count returns the number of live cells in the rectangle r, given as
+// offsets from (x, y) with r.Max exclusive.
+func (s *summedArea) count(x, y int, r image.Rectangle) int {
+	x0, x1 := x+r.Min.X+s.margin, x+r.Max.X+s.margin
+	y0, y1 := (y+r.Min.Y+s.margin)*s.stride, (y+r.Max.Y+s.margin)*s.stride
+	return int(s.sums[y1+x1] - s.sums[y0+x1] - s.sums[y1+x0] + s.sums[y0+x0])
+}
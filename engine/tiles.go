@@ -0,0 +1,120 @@
+package engine
+
+// tileSize is the side, in cells, of the square tiles a generation is split
+// into. Each tile is one job for the worker pool.
+const tileSize = 32
+
+// tileState is what the latest generation left in one tile.
+type tileState struct {
+	tally tally // Live cells of the tile
+	quiet int   // Generations in a row in which no cell of the tile flipped
+}
+
+// tileResult is what a worker found while computing one tile.
+type tileResult struct {
+	tally tally
+	flips []cellFlip // Cells that changed, in row-major order within the tile
+}
+
+// tileGrid tracks which tiles changed in the latest generations. A tile whose
+// neighborhood did not change cannot change either, so it is skipped, which
+// makes the cost of a tick follow the activity on the board rather than its
+// area. Under rules that cycle through phases, the neighborhood has to have
+// stayed the same for a whole cycle.
+type tileGrid struct {
+	cols, rows int
+	reachX     int // How many tiles away a change can have an effect
+	reachY     int
+	states     []tileState
+	results    []tileResult
+	active     []int // Tiles that have to be computed this generation
+}
+
+// invalidate forgets what is known about the tiles of a board of the given
+// size, evolving under a rule whose neighborhood has the given radius, so
+// that every tile is computed again. It is called whenever cells change
+// outside of a tick.
+func (t *tileGrid) invalidate(width, height, radius int) {
+	t.cols = (width + tileSize - 1) / tileSize
+	t.rows = (height + tileSize - 1) / tileSize
+	t.reachX = tileReach(width, radius)
+	t.reachY = tileReach(height, radius)
+	n := t.cols * t.rows
+	if len(t.states) != n {
+		t.states = make([]tileState, n)
+		t.results = make([]tileResult, n)
+	}
+	for i := range t.states {
+		t.states[i] = tileState{}
+	}
+}
+
+// tileReach returns how many tiles away along a side of the given length a
+// change can have an effect under a neighborhood of the given radius. The
+// last tile is cut short when the side is not a multiple of tileSize, and
+// since tiles wrap around it sits between the two ends, so a radius longer
+// than it reaches one tile further.
+func tileReach(side, radius int) int {
+	reach := max((radius+tileSize-1)/tileSize, 1)
+	if last := side % tileSize; last != 0 && last < radius {
+		reach++
+	}
+	return reach
+}
+
+// bounds returns the cells covered by tile i, clipped to the board.
+func (t *tileGrid) bounds(i, width, height int) (x0, y0, x1, y1 int) {
+	x0 = (i % t.cols) * tileSize
+	y0 = (i / t.cols) * tileSize
+	return x0, y0, min(x0+tileSize, width), min(y0+tileSize, height)
+}
+
+// canSkip reports whether neither tile i nor any tile within reach of it
+// changed in the latest generations, as many as the rule has phases. Its
+// next generation is then the same as the current one, which is also what
+// the spare buffer still holds.
+func (t *tileGrid) canSkip(i, phases int) bool {
+	tx, ty := i%t.cols, i/t.cols
+	for dy := -t.reachY; dy <= t.reachY; dy++ {
+		for dx := -t.reachX; dx <= t.reachX; dx++ {
+			// Tiles wrap around the edges just like cells do
+			nx := wrap(tx+dx, t.cols)
+			ny := wrap(ty+dy, t.rows)
+			if t.states[ny*t.cols+nx].quiet < phases {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// schedule returns the tiles that have to be computed for the next
+// generation under a rule with the given number of phases. The results of
+// all other tiles are filled in right away. Unless skip is set, every tile
+// is computed.
+func (t *tileGrid) schedule(phases int, skip bool) []int {
+	t.active = t.active[:0]
+	for i := range t.results {
+		if !skip || !t.canSkip(i, phases) {
+			t.active = append(t.active, i)
+			continue
+		}
+		// Nothing near the tile changed, so neither will the tile
+		r := &t.results[i]
+		r.tally = t.states[i].tally
+		r.tally.births, r.tally.deaths = 0, 0
+		r.flips = r.flips[:0]
+	}
+	return t.active
+}
+
+// update records the outcome of the generation just computed.
+func (t *tileGrid) update() {
+	for i, r := range t.results {
+		quiet := 0
+		if len(r.flips) == 0 {
+			quiet = t.states[i].quiet + 1
+		}
+		t.states[i] = tileState{tally: r.tally, quiet: quiet}
+	}
+}
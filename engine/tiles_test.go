@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// tileGenerations is how many generations the tile tests run, long enough
+// for a glider to cross several tiles and for soups to leave quiet regions.
+const tileGenerations = 120
+
+// assertStepsMatch runs cells under the rule for tileGenerations and fails
+// as soon as the tiled, skipping Step disagrees with computing every cell of
+// the board. It returns how many tile computations were skipped on the way.
+func assertStepsMatch(t *testing.T, rule Rule, cells [][]uint8) int {
+	t.Helper()
+	u := NewUniverse(len(cells[0]), len(cells))
+	u.SetRule(rule)
+	// The universe takes over the board it loads, and tests share theirs
+	u.Load(cloneGrid(cells), blankColors(cells))
+	want := snapshot(u)
+	skipped := 0
+	for gen := 1; gen <= tileGenerations; gen++ {
+		for i := range u.tiles.states {
+			if u.tiles.canSkip(i, rulePhases(rule)) {
+				skipped++
+			}
+		}
+		u.Step()
+		want = nextBoardAfter(rule, want, gen-1)
+		assertBoard(t, u, gen, want)
+	}
+	return skipped
+}
+
+// nextBoardAfter is like nextBoard, but applies the phase of rules that
+// cycle through phases that follows the given generation.
+func nextBoardAfter(rule Rule, cells [][]uint8, generation int) [][]uint8 {
+	phased, ok := rule.(phasedRule)
+	if !ok {
+		return nextBoard(rule, cells)
+	}
+	next := makeGrid[uint8](len(cells[0]), len(cells))
+	for y := range cells {
+		for x := range cells[y] {
+			next[y][x] = phased.nextInPhase(cells, x, y, generation%phased.phases())
+		}
+	}
+	return next
+}
+
+// cloneGrid returns a copy of a grid.
+func cloneGrid[T any](grid [][]T) [][]T {
+	clone := make([][]T, len(grid))
+	for y := range grid {
+		clone[y] = slices.Clone(grid[y])
+	}
+	return clone
+}
+
+// inverseParityTable is a rule file in which a cell is alive when an even
+// number of its von Neumann neighbors are. Dead cells with no live neighbors
+// are born, which rulestrings reject as B0, so quiet tiles do not stay quiet.
+const inverseParityTable = `@RULE InverseParity
+@TABLE
+n_states:2
+neighborhood:vonNeumann
+symmetries:permute
+var a={0,1}
+a,0,0,0,0,1
+a,1,0,0,0,0
+a,1,1,0,0,1
+a,1,1,1,0,0
+a,1,1,1,1,1
+`
+
+func TestTileSkipping(t *testing.T) {
+	// The board is not a multiple of the tile size, so edge tiles are partial
+	const width, height = 330, 200
+
+	// A small soup in one corner dies down and leaves most tiles quiet
+	corner := makeGrid[uint8](width, height)
+	patch, _ := soup(48, 40, 0.35, 7)
+	for y := range patch {
+		copy(corner[y][width-24:], patch[y][:24])
+		copy(corner[y], patch[y][24:])
+	}
+
+	dense, _ := soup(width, height, 0.35, 3)
+	tests := []struct {
+		name     string
+		rule     string
+		cells    [][]uint8
+		skipping bool // Whether some tiles must have been skipped
+	}{
+		{"dense soup", "life", dense, false},
+		{"soup across the edge", "life", corner, true},
+		{"glider", "life", boardWith(width, height, 60, 60, glider...), true},
+		{"brian's brain", "brians-brain", corner, true},
+		{"hexagonal", "B2/S34H", corner, false},
+		{"radius 2 mask", "B3/S2N@0a8822a", corner, true},
+		{"births from nothing", inverseParityTable, corner, false},
+		{"critters", "critters", corner, false},
+		{"sand", "sand", corner, true},
+	}
+	defer SetWorkers(0)
+	for _, workers := range []int{1, 5} {
+		SetWorkers(workers)
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/%d workers", tt.name, workers), func(t *testing.T) {
+				rule, err := ParseRule(tt.rule)
+				if strings.HasPrefix(tt.rule, "@RULE") {
+					rule, err = ReadRuleFile(strings.NewReader(tt.rule))
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if skipped := assertStepsMatch(t, rule, tt.cells); tt.skipping && skipped == 0 {
+					t.Error("no tile was ever skipped")
+				}
+			})
+		}
+	}
+}
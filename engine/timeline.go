@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Timeline geometry, in screen pixels
+const (
+	timelineHeight  = 12
+	timelineMargin  = 10
+	timelineReserve = timelineHeight + timelineMargin + statusBarHeight // Space kept free at the bottom of the screen
+)
+
+var (
+	timelineBackground = color.RGBA{R: 0, G: 0, B: 0, A: 180}
+	timelineFill       = color.RGBA{R: 80, G: 140, B: 220, A: 255}
+	timelineHandle     = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+)
+
+// timelineRect returns the position and size of the timeline bar on a screen of the given size.
+func timelineRect(screenWidth, screenHeight int) (x, y, w, h float32) {
+	return timelineMargin, float32(screenHeight - timelineReserve), float32(screenWidth - 2*timelineMargin), timelineHeight
+}
+
+// timelineGeneration maps a screen position to the generation under it, if
+// the position is on the timeline.
+func (g *Game) timelineGeneration(mx, my, screenWidth, screenHeight int) (int, bool) {
+	x, y, w, h := timelineRect(screenWidth, screenHeight)
+	if float32(mx) < x || float32(mx) > x+w || float32(my) < y || float32(my) > y+h {
+		return 0, false
+	}
+	history := g.universe.History()
+	span := history.Newest() - history.Oldest()
+	return history.Oldest() + int(float32(span)*(float32(mx)-x)/w+0.5), true
+}
+
+// drawTimeline draws the recorded history as a bar with a handle at the current generation.
+func (g *Game) drawTimeline(screen *ebiten.Image) {
+	bounds := screen.Bounds()
+	x, y, w, h := timelineRect(bounds.Dx(), bounds.Dy())
+	vector.DrawFilledRect(screen, x, y, w, h, timelineBackground, false)
+
+	history := g.universe.History()
+	span := history.Newest() - history.Oldest()
+	pos := float32(1)
+	if span > 0 {
+		pos = float32(g.universe.Generation()-history.Oldest()) / float32(span)
+	}
+	vector.DrawFilledRect(screen, x, y, w*pos, h, timelineFill, false)
+	vector.DrawFilledRect(screen, x+w*pos-1, y-2, 3, h+4, timelineHandle, false)
+
+	label := fmt.Sprintf("%d .. %d", history.Oldest(), history.Newest())
+	ebitenutil.DebugPrintAt(screen, label, int(x), int(y)-16)
+}
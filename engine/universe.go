@@ -0,0 +1,518 @@
+package engine
+
+import (
+	"image/color"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+)
+
+// Universe holds the state of a toroidal cellular automaton board and
+// advances it one generation at a time under its rule, Conway's Game of Life
+// unless told otherwise. It has no dependency on the GUI, so it can be driven
+// headlessly by experiments.
+type Universe struct {
+	width, height int
+	rule          Rule
+	cells         [][]uint8 // State of each cell, 0 for dead
+	colors        [][]color.RGBA
+	nextCells     [][]uint8
+	born          [][]int32 // Generation each live cell was born in
+	changed       [][]int32 // Generation each cell last flipped in, or neverChanged
+	scheme        ColorScheme
+	rng           *rand.Rand // Randomness for work done outside the tick workers
+	seed          uint64     // Seed of the chances of stochastic rules and updates
+	update        UpdateMode
+	fraction      float64 // Chance that a cell is updated under FractionUpdate
+	tiles         tileGrid
+	sums          summedArea // Live cell counts for rules of extended range
+	generation    int
+	detector      *Detector
+	stats         *StatsHistory
+	history       *History
+	undo, redo    []edit
+}
+
+// NewUniverse creates an empty universe of the given size.
+func NewUniverse(width, height int) *Universe {
+	u := &Universe{
+		width:     width,
+		height:    height,
+		rule:      Life,
+		cells:     makeGrid[uint8](width, height),
+		colors:    makeGrid[color.RGBA](width, height),
+		nextCells: makeGrid[uint8](width, height),
+		born:      makeGrid[int32](width, height),
+		changed:   makeActivityGrid(width, height),
+		scheme:    RandomScheme{},
+		rng:       newRNG(),
+		fraction:  1,
+		detector:  NewDetector(DefaultMaxPeriod),
+		stats:     NewStatsHistory(DefaultStatsCapacity),
+		history:   NewHistory(DefaultHistoryCapacity, DefaultKeyframeEvery),
+	}
+	u.seed = u.rng.Uint64()
+	u.reset()
+	return u
+}
+
+// Load replaces the board contents, resets the generation counter and
+// forgets everything the period detector and the history have seen. States
+// the rule does not have are cleared.
+func (u *Universe) Load(cells [][]uint8, colors [][]color.RGBA) {
+	u.cells = cells
+	u.colors = colors
+	u.generation = 0
+	u.clampStates()
+	u.clearActivity()
+	u.recolor()
+	u.reset()
+}
+
+// SetRule changes the rule the universe evolves under. Cells in states the
+// new rule does not have are cleared, and since the recorded future no
+// longer applies, detection, statistics and history restart from the
+// current board. Rules that need an even board crop it as BoardSize says.
+func (u *Universe) SetRule(rule Rule) {
+	u.rule = rule
+	u.clampStates()
+	if width, height := BoardSize(rule, u.width, u.height); width != u.width || height != u.height {
+		u.Resize(width, height)
+		return
+	}
+	u.reset()
+}
+
+// Rule returns the rule the universe evolves under.
+func (u *Universe) Rule() Rule {
+	return u.rule
+}
+
+// clampStates clears cells in states the rule does not have.
+func (u *Universe) clampStates() {
+	states := u.rule.States()
+	for _, row := range u.cells {
+		for x, state := range row {
+			if int(state) >= states {
+				row[x] = 0
+			}
+		}
+	}
+}
+
+// newRNG returns a generator with a random seed.
+func newRNG() *rand.Rand {
+	return rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+}
+
+// makeGrid allocates a zeroed height x width grid.
+func makeGrid[T any](width, height int) [][]T {
+	grid := make([][]T, height)
+	for i := range grid {
+		grid[i] = make([]T, width)
+	}
+	return grid
+}
+
+// makeActivityGrid allocates a height x width grid of cells that never changed.
+func makeActivityGrid(width, height int) [][]int32 {
+	grid := makeGrid[int32](width, height)
+	for _, row := range grid {
+		for x := range row {
+			row[x] = neverChanged
+		}
+	}
+	return grid
+}
+
+// reset restarts detection, statistics and history from the current board.
+func (u *Universe) reset() {
+	u.detector.SetPhases(rulePhases(u.rule))
+	u.detector.Reset()
+	u.detector.Observe(u.cells, u.generation)
+	u.stats.Reset()
+	u.stats.Add(u.census())
+	u.history.Reset(u.cells, u.generation)
+	u.undo = nil
+	u.redo = nil
+	u.tiles.invalidate(u.width, u.height, u.rule.Neighborhood().Radius())
+}
+
+// SetSeed sets the seed of the chances of stochastic rules and of
+// asynchronous updates. From the same board, rule and update mode, the same
+// seed always leads to the same generations.
+func (u *Universe) SetSeed(seed uint64) {
+	u.seed = seed
+}
+
+// Seed returns the seed of the chances of stochastic rules and of
+// asynchronous updates, which is random unless set.
+func (u *Universe) Seed() uint64 {
+	return u.seed
+}
+
+// SetUpdateMode selects which cells are updated every generation. Under
+// FractionUpdate, each cell is updated with the given chance.
+func (u *Universe) SetUpdateMode(mode UpdateMode, fraction float64) {
+	u.update = mode
+	u.fraction = fraction
+	u.tiles.invalidate(u.width, u.height, u.rule.Neighborhood().Radius())
+}
+
+// UpdateMode returns the mode cells are updated in under the current rule.
+// Block rules and one-dimensional rules only work with all cells updated
+// together, so they always update synchronously.
+func (u *Universe) UpdateMode() UpdateMode {
+	switch baseRule(u.rule).(type) {
+	case phasedRule, *WolframRule:
+		return SyncUpdate
+	}
+	return u.update
+}
+
+// updateSequentially computes the next generation into nextCells by
+// updating as many randomly picked cells as the board has, one after the
+// other, so that every update sees the ones before it. Some cells are picked
+// more than once and others not at all.
+func (u *Universe) updateSequentially() {
+	for y := range u.cells {
+		copy(u.nextCells[y], u.cells[y])
+	}
+	dice := newDice(u.seed, u.generation+1)
+	rule := baseRule(u.rule)
+	stochastic, isStochastic := u.rule.(*StochasticRule)
+	n := u.width * u.height
+	for i := 0; i < n; i++ {
+		index := int(dice.roll(i, pickSalt) * float64(n))
+		x, y := index%u.width, index/u.width
+		state := u.nextCells[y][x]
+		next := rule.Next(u.nextCells, x, y)
+		if isStochastic {
+			next = stochastic.perturb(state, next, dice.roll(i, ruleSalt))
+		}
+		u.nextCells[y][x] = next
+	}
+}
+
+// Width returns the width of the board in cells.
+func (u *Universe) Width() int {
+	return u.width
+}
+
+// Height returns the height of the board in cells.
+func (u *Universe) Height() int {
+	return u.height
+}
+
+// Generation returns the number of generations computed since the last Load.
+func (u *Universe) Generation() int {
+	return u.generation
+}
+
+// Alive reports whether the cell at (x, y) is in any state but dead.
+func (u *Universe) Alive(x, y int) bool {
+	return u.cells[y][x] != 0
+}
+
+// State returns the state of the cell at (x, y).
+func (u *Universe) State(x, y int) uint8 {
+	return u.cells[y][x]
+}
+
+// Color returns the display color of the cell at (x, y).
+func (u *Universe) Color(x, y int) color.RGBA {
+	return u.colors[y][x]
+}
+
+// SetColorScheme changes how cells are colored from now on.
+func (u *Universe) SetColorScheme(scheme ColorScheme) {
+	u.scheme = scheme
+	u.recolor()
+}
+
+// recolor maps the colors of all live cells onto the palette of the color
+// scheme, if it has one.
+func (u *Universe) recolor() {
+	recolorer, ok := u.scheme.(Recolorer)
+	if !ok {
+		return
+	}
+	for y := range u.cells {
+		for x, state := range u.cells[y] {
+			if state != 0 {
+				u.colors[y][x] = recolorer.Recolor(u.colors[y][x])
+			}
+		}
+	}
+}
+
+// ColorScheme returns the color scheme in use.
+func (u *Universe) ColorScheme() ColorScheme {
+	return u.scheme
+}
+
+// BirthColor returns the color the current scheme gives a cell set alive by hand.
+func (u *Universe) BirthColor() color.RGBA {
+	return u.scheme.Birth(nil, u.rng)
+}
+
+// CellInfo returns what a color scheme needs to know about the cell at (x, y).
+func (u *Universe) CellInfo(x, y int) CellInfo {
+	return CellInfo{
+		Alive: u.cells[y][x] != 0,
+		State: u.cells[y][x],
+		Age:   u.generation - int(u.born[y][x]),
+		Heat:  heatAfter(u.generation - int(u.changed[y][x])),
+		Color: u.colors[y][x],
+	}
+}
+
+// CellColor returns the display color of the cell at (x, y) under the
+// current color scheme, or false if the cell should not be drawn. Cells in
+// the refractory states of multi-state rules fade out from the color the
+// scheme gives them, and rules that color their states, such as those from
+// .rule files, override the scheme.
+func (u *Universe) CellColor(x, y int) (color.RGBA, bool) {
+	if r, ok := baseRule(u.rule).(stateColorer); ok {
+		state := u.cells[y][x]
+		return r.stateColor(state), state != 0
+	}
+	c, ok := u.scheme.CellColor(u.CellInfo(x, y))
+	if state := int(u.cells[y][x]); ok && state > 1 {
+		c = fade(c, state-1, u.rule.States()-1)
+	}
+	return c, ok
+}
+
+// StateColor returns the color cells in the given state are drawn in when
+// the rule colors its states, and otherwise a gray that darkens along with
+// the refractory states of multi-state rules. It is meant for legends and
+// palettes rather than for drawing the board.
+func (u *Universe) StateColor(state uint8) color.RGBA {
+	if r, ok := baseRule(u.rule).(stateColorer); ok {
+		return r.stateColor(state)
+	}
+	if state == 0 {
+		return color.RGBA{A: 255}
+	}
+	return fade(color.RGBA{R: 220, G: 220, B: 220, A: 255}, int(state)-1, u.rule.States()-1)
+}
+
+// StateName returns the name of the given state, such as "conductor" for
+// WireWorld, or its number if the rule does not name its states.
+func (u *Universe) StateName(state uint8) string {
+	if r, ok := baseRule(u.rule).(stateNamer); ok {
+		return r.stateName(state)
+	}
+	return strconv.Itoa(int(state))
+}
+
+// clearActivity forgets cell ages and recent activity, for when the board
+// jumps to a state whose past is unknown.
+func (u *Universe) clearActivity() {
+	for y := range u.born {
+		for x := range u.born[y] {
+			u.born[y][x] = int32(u.generation)
+			u.changed[y][x] = neverChanged
+		}
+	}
+}
+
+// markChanged records that the given cells just changed, outside of a tick.
+func (u *Universe) markChanged(flips []cellFlip) {
+	for _, f := range flips {
+		x, y := int(f.index)%u.width, int(f.index)/u.width
+		u.born[y][x] = int32(u.generation)
+		u.changed[y][x] = int32(u.generation)
+	}
+}
+
+// Detection returns the latest result of period and stabilization detection.
+func (u *Universe) Detection() Detection {
+	return u.detector.Result()
+}
+
+// Stats returns the per-generation statistics recorded since the last Load.
+func (u *Universe) Stats() *StatsHistory {
+	return u.stats
+}
+
+// Population returns the number of live cells.
+func (u *Universe) Population() int {
+	latest, _ := u.stats.Latest()
+	return latest.Population
+}
+
+// census counts the live cells of the current generation from scratch.
+func (u *Universe) census() Stats {
+	t := newTally()
+	for y := 0; y < u.height; y++ {
+		for x := 0; x < u.width; x++ {
+			if u.cells[y][x] != 0 {
+				t.addAlive(x, y)
+			}
+		}
+	}
+	return t.stats(u.generation, u.width*u.height)
+}
+
+// RunUntilSettled steps the universe until it dies out, settles into a still
+// life or oscillator, or starts translating, or until maxGenerations further
+// generations have been computed. It returns the final detection result.
+func (u *Universe) RunUntilSettled(maxGenerations int) Detection {
+	for i := 0; i < maxGenerations && !u.Detection().Settled(); i++ {
+		u.Step()
+	}
+	return u.Detection()
+}
+
+// maxParents is the most parents a newborn takes its color from. Under
+// neighborhoods of extended range only the nearest live cells count.
+const maxParents = 8
+
+// parentColors appends the colors of the nearest neighbors of (x, y) in
+// state 1 to buf.
+func (u *Universe) parentColors(x, y int, buf []color.RGBA) []color.RGBA {
+	for _, o := range u.rule.Neighborhood().offsets {
+		nx, ny := wrap(x+o.X, u.width), wrap(y+o.Y, u.height)
+		if u.cells[ny][nx] == 1 {
+			buf = append(buf, u.colors[ny][nx])
+			if len(buf) == maxParents {
+				break
+			}
+		}
+	}
+	return buf
+}
+
+// Step advances the universe by one generation. If the universe was rewound,
+// the recorded generation is replayed instead of being recomputed.
+func (u *Universe) Step() {
+	if u.generation < u.history.Newest() {
+		u.replay(u.generation + 1)
+		return
+	}
+
+	// Chance can change any cell at any time, so no tile can be skipped
+	mode := u.UpdateMode()
+	_, isStochastic := u.rule.(*StochasticRule)
+	tiles := &u.tiles
+	active := tiles.schedule(rulePhases(u.rule), mode == SyncUpdate && !isStochastic)
+	if mode == SequentialUpdate {
+		u.updateSequentially()
+	} else if r, ok := baseRule(u.rule).(summingRule); ok && len(active) > 0 {
+		u.sums.build(u.cells, r.Neighborhood().Radius())
+	}
+	getPool().run(len(active), func(i int, w *worker) {
+		u.stepTile(active[i], w, &tiles.results[active[i]], mode)
+	})
+	tiles.update()
+
+	// Swap cells and nextCells
+	u.cells, u.nextCells = u.nextCells, u.cells
+	// Increment generation
+	u.generation++
+
+	total := newTally()
+	var delta []cellFlip
+	for _, r := range tiles.results {
+		total.merge(r.tally)
+		delta = append(delta, r.flips...)
+	}
+	s := total.stats(u.generation, u.width*u.height)
+	u.stats.Add(s)
+	u.detector.ObserveStats(u.cells, s)
+
+	// Tiles split rows, so their flips have to be sorted for the history
+	slices.SortFunc(delta, compareFlips)
+	u.history.Record(u.generation, delta, u.cells)
+}
+
+// stepTile computes the next generation of the cells in tile i into
+// nextCells, recording what happened in r. Under sequential updates
+// nextCells already holds the next generation.
+func (u *Universe) stepTile(i int, w *worker, r *tileResult, mode UpdateMode) {
+	r.tally = newTally()
+	r.flips = r.flips[:0]
+	x0, y0, x1, y1 := u.tiles.bounds(i, u.width, u.height)
+	gen := int32(u.generation + 1)
+	rule := baseRule(u.rule)
+	stochastic, isStochastic := u.rule.(*StochasticRule)
+	summing, isSumming := rule.(summingRule)
+	phased, isPhased := rule.(phasedRule)
+	phase := 0
+	if isPhased {
+		phase = u.generation % phased.phases()
+	}
+	dice := newDice(u.seed, int(gen))
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			state := u.cells[y][x]
+			next := state
+			updated := true
+			switch {
+			case mode == SequentialUpdate:
+				next = u.nextCells[y][x]
+			case mode == FractionUpdate && dice.roll(y*u.width+x, updateSalt) >= u.fraction:
+				// The cell sits this generation out, chance and all
+				updated = false
+			case isSumming:
+				next = summing.nextFromSums(&u.sums, u.cells, x, y)
+			case isPhased:
+				next = phased.nextInPhase(u.cells, x, y, phase)
+			default:
+				next = rule.Next(u.cells, x, y)
+			}
+			if isStochastic && updated && mode != SequentialUpdate {
+				next = stochastic.perturb(state, next, dice.roll(y*u.width+x, ruleSalt))
+			}
+			u.nextCells[y][x] = next
+			if next != 0 {
+				r.tally.addAlive(x, y)
+			}
+			if next == state {
+				continue
+			}
+
+			r.flips = append(r.flips, cellFlip{index: int32(y*u.width + x), mask: state ^ next})
+			u.changed[y][x] = gen
+			switch {
+			case state == 0:
+				r.tally.births++
+				u.born[y][x] = gen
+				// Let the color scheme pick a color for the new cell from its parents
+				u.colors[y][x] = u.scheme.Birth(u.parentColors(x, y, w.parents[:0]), w.rng)
+			case next == 0:
+				r.tally.deaths++
+			}
+		}
+	}
+}
+
+// Resize changes the board dimensions, keeping the cells that still fit.
+// Rules that need an even board round the dimensions down as BoardSize says.
+func (u *Universe) Resize(newWidth, newHeight int) {
+	newWidth, newHeight = BoardSize(u.rule, newWidth, newHeight)
+	// Create new slices with updated dimensions
+	newCells := makeGrid[uint8](newWidth, newHeight)
+	newColors := makeGrid[color.RGBA](newWidth, newHeight)
+	newBorn := makeGrid[int32](newWidth, newHeight)
+	newChanged := makeActivityGrid(newWidth, newHeight)
+	// Copy existing data if within old bounds
+	for y := 0; y < newHeight && y < u.height; y++ {
+		copy(newCells[y], u.cells[y])
+		copy(newColors[y], u.colors[y])
+		copy(newBorn[y], u.born[y])
+		copy(newChanged[y], u.changed[y])
+	}
+
+	// Replace old slices with new ones
+	u.cells = newCells
+	u.colors = newColors
+	u.nextCells = makeGrid[uint8](newWidth, newHeight)
+	u.born = newBorn
+	u.changed = newChanged
+	u.width = newWidth
+	u.height = newHeight
+	u.reset()
+}
@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// VideoFormat is a stream of uncompressed frames that video encoders read.
+type VideoFormat int
+
+const (
+	RawRGB VideoFormat = iota // Three bytes per pixel, red, green and blue, with nothing between frames
+	Y4M                       // YUV4MPEG2 with 4:2:0 chroma, which carries its own size and frame rate
+)
+
+// videoFormatNames maps video formats to their names.
+var videoFormatNames = map[VideoFormat]string{
+	RawRGB: "rgb",
+	Y4M:    "y4m",
+}
+
+// String returns the name of the video format.
+func (f VideoFormat) String() string { return videoFormatNames[f] }
+
+// ParseVideoFormat returns the video format with the given name.
+func ParseVideoFormat(name string) (VideoFormat, error) {
+	for format, formatName := range videoFormatNames {
+		if formatName == name {
+			return format, nil
+		}
+	}
+	return RawRGB, fmt.Errorf("unknown video format '%s'", name)
+}
+
+// VideoWriter writes frames of the same size to a stream in a video format,
+// for piping into an encoder.
+type VideoWriter struct {
+	w             io.Writer
+	format        VideoFormat
+	fps           int
+	width, height int    // Size of the frames, set by the first one
+	buf           []byte // One frame in the format
+}
+
+// NewVideoWriter returns a writer of frames to w, shown fps frames per
+// second.
+func NewVideoWriter(w io.Writer, format VideoFormat, fps int) *VideoWriter {
+	return &VideoWriter{w: w, format: format, fps: fps}
+}
+
+// WriteFrame writes a frame. Every frame must be the size of the first one,
+// and Y4M frames must be of even width and height.
+func (v *VideoWriter) WriteFrame(img *image.RGBA) error {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	if v.buf == nil {
+		if v.format == Y4M && (width%2 != 0 || height%2 != 0) {
+			return fmt.Errorf("y4m frames must be of even width and height, not %dx%d", width, height)
+		}
+		v.width, v.height = width, height
+		if v.format == Y4M {
+			header := fmt.Sprintf("YUV4MPEG2 W%d H%d F%d:1 Ip A1:1 C420jpeg\n", width, height, v.fps)
+			if _, err := io.WriteString(v.w, header); err != nil {
+				return err
+			}
+		}
+	} else if width != v.width || height != v.height {
+		return fmt.Errorf("frame is %dx%d, not %dx%d like the first", width, height, v.width, v.height)
+	}
+
+	switch v.format {
+	case RawRGB:
+		v.buf = v.buf[:0]
+		for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+			for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+				c := img.RGBAAt(x, y)
+				v.buf = append(v.buf, c.R, c.G, c.B)
+			}
+		}
+	case Y4M:
+		v.buf = append(v.buf[:0], "FRAME\n"...)
+		v.buf = appendYCbCr420(v.buf, img)
+	}
+	_, err := v.w.Write(v.buf)
+	return err
+}
+
+// appendYCbCr420 appends the planes of an image of even size in full range
+// YCbCr: the luma of every pixel, then the blue and the red chroma of every
+// two by two pixels.
+func appendYCbCr420(buf []byte, img *image.RGBA) []byte {
+	b := img.Rect
+	cb := make([]byte, 0, b.Dx()*b.Dy()/4)
+	cr := make([]byte, 0, b.Dx()*b.Dy()/4)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			luma, _, _ := color.RGBToYCbCr(c.R, c.G, c.B)
+			buf = append(buf, luma)
+		}
+	}
+	for y := b.Min.Y; y < b.Max.Y; y += 2 {
+		for x := b.Min.X; x < b.Max.X; x += 2 {
+			var red, green, blue int
+			for _, p := range [4]image.Point{{x, y}, {x + 1, y}, {x, y + 1}, {x + 1, y + 1}} {
+				c := img.RGBAAt(p.X, p.Y)
+				red, green, blue = red+int(c.R), green+int(c.G), blue+int(c.B)
+			}
+			_, blueDiff, redDiff := color.RGBToYCbCr(uint8(red/4), uint8(green/4), uint8(blue/4))
+			cb, cr = append(cb, blueDiff), append(cr, redDiff)
+		}
+	}
+	return append(append(buf, cb...), cr...)
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// solidFrame returns a frame of the given size in one color.
+func solidFrame(width, height int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+func TestVideoWriterY4M(t *testing.T) {
+	var buf bytes.Buffer
+	v := NewVideoWriter(&buf, Y4M, 30)
+	for i := 0; i < 3; i++ {
+		if err := v.WriteFrame(solidFrame(6, 4, color.RGBA{R: 255, A: 255})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	header, frames, _ := strings.Cut(buf.String(), "\n")
+	if want := "YUV4MPEG2 W6 H4 F30:1 Ip A1:1 C420jpeg"; header != want {
+		t.Errorf("header %q, want %q", header, want)
+	}
+	// Each frame is a FRAME line, then a luma byte for every pixel and two
+	// chroma bytes for every two by two pixels
+	frameSize := len("FRAME\n") + 6*4 + 2*3*2
+	if len(frames) != 3*frameSize {
+		t.Fatalf("%d bytes of frames, want 3 of %d", len(frames), frameSize)
+	}
+	luma, cb, cr := color.RGBToYCbCr(255, 0, 0)
+	frame := frames[:frameSize]
+	want := "FRAME\n" + strings.Repeat(string([]byte{luma}), 24) + strings.Repeat(string([]byte{cb}), 6) + strings.Repeat(string([]byte{cr}), 6)
+	if frame != want {
+		t.Errorf("frame %q, want %q", frame, want)
+	}
+}
+
+func TestVideoWriterRGB(t *testing.T) {
+	var buf bytes.Buffer
+	v := NewVideoWriter(&buf, RawRGB, 30)
+	img := solidFrame(3, 2, color.RGBA{R: 1, G: 2, B: 3, A: 255})
+	img.SetRGBA(2, 1, color.RGBA{R: 4, G: 5, B: 6, A: 255})
+	for i := 0; i < 2; i++ {
+		if err := v.WriteFrame(img); err != nil {
+			t.Fatal(err)
+		}
+	}
+	frame := strings.Repeat("\x01\x02\x03", 5) + "\x04\x05\x06"
+	if got := buf.String(); got != frame+frame {
+		t.Errorf("wrote %q, want %q twice", got, frame)
+	}
+}
+
+func TestVideoWriterErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewVideoWriter(&buf, Y4M, 30).WriteFrame(solidFrame(5, 4, color.RGBA{})); err == nil {
+		t.Error("y4m frame of odd width accepted")
+	}
+	v := NewVideoWriter(&buf, RawRGB, 30)
+	if err := v.WriteFrame(solidFrame(4, 4, color.RGBA{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.WriteFrame(solidFrame(4, 6, color.RGBA{})); err == nil {
+		t.Error("frame of another size accepted")
+	}
+}
+
+func TestParseVideoFormat(t *testing.T) {
+	for _, format := range []VideoFormat{RawRGB, Y4M} {
+		got, err := ParseVideoFormat(format.String())
+		if err != nil || got != format {
+			t.Errorf("%s: got %v, %v", format, got, err)
+		}
+	}
+	if _, err := ParseVideoFormat("mp4"); err == nil {
+		t.Error("unknown video format accepted")
+	}
+}
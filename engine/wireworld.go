@@ -0,0 +1,65 @@
+package engine
+
+import "image/color"
+
+// States of WireWorld cells, written '.', 'A', 'B' and 'C' in RLE files.
+const (
+	WireEmpty uint8 = iota
+	WireHead
+	WireTail
+	WireConductor
+)
+
+// WireWorld is Brian Silverman's WireWorld, in which electrons travel along
+// conductors: heads become tails, tails become conductor, and conductor
+// becomes a head next to one or two heads. Logic gates and whole computers
+// have been built from it.
+var WireWorld Rule = wireWorldRule{}
+
+// wireWorldColors are the colors of the WireWorld states, as in Golly.
+var wireWorldColors = [4]color.RGBA{
+	WireEmpty:     {R: 48, G: 48, B: 48, A: 255},
+	WireHead:      {R: 0, G: 128, B: 255, A: 255},
+	WireTail:      {R: 255, G: 255, B: 255, A: 255},
+	WireConductor: {R: 255, G: 128, A: 255},
+}
+
+// wireWorldNames are the names of the WireWorld states.
+var wireWorldNames = [4]string{"empty", "head", "tail", "conductor"}
+
+type wireWorldRule struct{}
+
+func (wireWorldRule) String() string { return "WireWorld" }
+
+func (wireWorldRule) States() int { return 4 }
+
+func (wireWorldRule) Neighborhood() *Neighborhood { return Moore }
+
+func (wireWorldRule) Next(cells [][]uint8, x, y int) uint8 {
+	switch cells[y][x] {
+	case WireHead:
+		return WireTail
+	case WireTail:
+		return WireConductor
+	case WireConductor:
+		height, width := len(cells), len(cells[0])
+		left, right := (x+width-1)%width, (x+1)%width
+		heads := 0
+		for _, row := range [3][]uint8{cells[(y+height-1)%height], cells[y], cells[(y+1)%height]} {
+			for _, s := range [3]uint8{row[left], row[x], row[right]} {
+				if s == WireHead {
+					heads++
+				}
+			}
+		}
+		if heads == 1 || heads == 2 {
+			return WireHead
+		}
+		return WireConductor
+	}
+	return WireEmpty
+}
+
+func (wireWorldRule) stateColor(state uint8) color.RGBA { return wireWorldColors[state] }
+
+func (wireWorldRule) stateName(state uint8) string { return wireWorldNames[state] }
@@ -0,0 +1,42 @@
+package engine
+
+import "testing"
+
+func TestWireWorldClock(t *testing.T) {
+	// patterns/wireworldclock.rle, with 1 for heads, 2 for tails and 3 for
+	// conductor
+	cells := boardWith(40, 10, 5, 4, ".3333", "3....333333", ".3123")
+	u := NewUniverse(40, 10)
+	u.SetRule(WireWorld)
+	u.Load(cells, blankColors(cells))
+
+	// Every ten generations an electron reaches the end of the wire
+	var arrivals []int
+	for gen := 1; gen <= 50; gen++ {
+		u.Step()
+		if u.State(15, 5) == WireHead {
+			arrivals = append(arrivals, gen)
+		}
+	}
+	if len(arrivals) < 4 {
+		t.Fatalf("electrons reached the end of the wire in generations %v, want every tenth", arrivals)
+	}
+	for i := 1; i < len(arrivals); i++ {
+		if arrivals[i]-arrivals[i-1] != 10 {
+			t.Fatalf("electrons reached the end of the wire in generations %v, want every tenth", arrivals)
+		}
+	}
+
+	got := u.RunUntilSettled(100)
+	if got.Kind != Oscillator || got.Period != 10 {
+		t.Errorf("detected %v, want an oscillator of period 10", got)
+	}
+}
+
+func TestWireWorldMatchesTable(t *testing.T) {
+	table, err := LoadRuleFile("../rules/WireWorldTable.rule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameEvolution(t, WireWorld, table, randomCells(WireWorld, 64, 48, 1), 100)
+}
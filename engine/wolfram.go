@@ -0,0 +1,181 @@
+package engine
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math/big"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// Limits of one-dimensional rules, which keep their lookup tables small.
+const (
+	maxLineRange = 8
+	maxLineTable = 1 << 16
+)
+
+// Colors of the states of one-dimensional rules, from state 1 to the last.
+var (
+	lineFirstColor = color.RGBA{R: 96, G: 96, B: 96, A: 255}
+	lineLastColor  = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+)
+
+// WolframRule is a one-dimensional rule numbered as by Stephen Wolfram,
+// such as "W30" for elementary rule 30. The universe shows it as a
+// spacetime diagram: the bottom row is the current line, and every
+// generation the rows above scroll up by one, so that each row shows the
+// line one generation further in the past.
+//
+// The code, written in base k, lists the next state of a cell for every
+// configuration of the 2r+1 cells around it, read as a base k number with
+// the leftmost cell most significant. Totalistic rules, written with a T
+// instead of a W, list it for every sum of those cells instead.
+type WolframRule struct {
+	code         string
+	totalistic   bool
+	states       int // k
+	radius       int // r
+	table        []uint8
+	colors       []color.RGBA
+	neighborhood *Neighborhood
+}
+
+// parseWolfram parses a one-dimensional rule: W or T followed by the code in
+// decimal, optionally followed by the state count K and the range R, which
+// default to 2 and 1, as in "W30", "W3485,K3" or "T1599,K3".
+func parseWolfram(s string) (*WolframRule, error) {
+	parts := strings.Split(s, ",")
+	r := &WolframRule{states: 2, radius: 1, totalistic: strings.ToUpper(s[:1]) == "T"}
+	code, ok := new(big.Int).SetString(parts[0][1:], 10)
+	if !ok || code.Sign() < 0 {
+		return nil, fmt.Errorf("invalid code '%s'", parts[0][1:])
+	}
+	seen := map[byte]bool{}
+	for _, part := range parts[1:] {
+		if part == "" {
+			return nil, fmt.Errorf("empty part")
+		}
+		tag := strings.ToUpper(part[:1])[0]
+		if seen[tag] {
+			return nil, fmt.Errorf("'%c' given twice", tag)
+		}
+		seen[tag] = true
+		n, err := strconv.Atoi(part[1:])
+		switch {
+		case err != nil:
+			return nil, fmt.Errorf("invalid part '%s'", part)
+		case tag == 'K':
+			r.states = n
+		case tag == 'R':
+			r.radius = n
+		default:
+			return nil, fmt.Errorf("unknown part '%s'", part)
+		}
+	}
+	if r.states < 2 || r.states > 256 {
+		return nil, fmt.Errorf("state count must be between 2 and 256")
+	}
+	if r.radius < 1 || r.radius > maxLineRange {
+		return nil, fmt.Errorf("range must be between 1 and %d", maxLineRange)
+	}
+
+	width := 2*r.radius + 1
+	inputs := width*(r.states-1) + 1
+	if !r.totalistic {
+		inputs = 1
+		for i := 0; i < width; i++ {
+			if inputs *= r.states; inputs > maxLineTable {
+				return nil, fmt.Errorf("%d states and range %d need too large a table, use a totalistic rule", r.states, r.radius)
+			}
+		}
+	}
+	base := big.NewInt(int64(r.states))
+	if limit := new(big.Int).Exp(base, big.NewInt(int64(inputs)), nil); code.Cmp(limit) >= 0 {
+		return nil, fmt.Errorf("code must be less than %d^%d", r.states, inputs)
+	}
+	r.code = code.String()
+
+	r.table = make([]uint8, inputs)
+	digit := new(big.Int)
+	for i := range r.table {
+		code.DivMod(code, base, digit)
+		r.table[i] = uint8(digit.Int64())
+	}
+
+	r.colors = make([]color.RGBA, r.states)
+	for state := 1; state < r.states; state++ {
+		t := 1.0
+		if r.states > 2 {
+			t = float64(state-1) / float64(r.states-2)
+		}
+		r.colors[state] = gradient([]color.RGBA{lineFirstColor, lineLastColor}, t)
+	}
+
+	// The line itself and, for the rows above it, the cell below
+	offsets := []image.Point{{0, 1}}
+	for dx := -r.radius; dx <= r.radius; dx++ {
+		offsets = append(offsets, image.Pt(dx, 0))
+	}
+	r.neighborhood = newNeighborhood("", offsets)
+	return r, nil
+}
+
+// String returns the rule in the notation parseWolfram reads, leaving out
+// the state count and range when they are the defaults.
+func (r *WolframRule) String() string {
+	s := "W" + r.code
+	if r.totalistic {
+		s = "T" + r.code
+	}
+	if r.states != 2 {
+		s += ",K" + strconv.Itoa(r.states)
+	}
+	if r.radius != 1 {
+		s += ",R" + strconv.Itoa(r.radius)
+	}
+	return s
+}
+
+func (r *WolframRule) States() int { return r.states }
+
+func (r *WolframRule) Neighborhood() *Neighborhood { return r.neighborhood }
+
+// Next scrolls every row but the bottom one up from the row below it, and
+// applies the rule to the bottom row.
+func (r *WolframRule) Next(cells [][]uint8, x, y int) uint8 {
+	height, width := len(cells), len(cells[0])
+	if y < height-1 {
+		return cells[y+1][x]
+	}
+	row := cells[y]
+	index := 0
+	for dx := -r.radius; dx <= r.radius; dx++ {
+		state := int(row[wrap(x+dx, width)])
+		if r.totalistic {
+			index += state
+		} else {
+			index = index*r.states + state
+		}
+	}
+	return r.table[index]
+}
+
+func (r *WolframRule) stateColor(state uint8) color.RGBA { return r.colors[state] }
+
+// Seed returns a board for the rule that is empty but for its bottom row,
+// the current line, which holds either a single cell in state 1 in the
+// middle or cells in random states.
+func (r *WolframRule) Seed(width, height int, random bool) [][]uint8 {
+	cells := makeGrid[uint8](width, height)
+	line := cells[height-1]
+	if !random {
+		line[width/2] = 1
+		return cells
+	}
+	for x := range line {
+		line[x] = uint8(rand.IntN(r.states))
+	}
+	return cells
+}
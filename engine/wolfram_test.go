@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWolframFirstRows(t *testing.T) {
+	tests := []struct {
+		rule string
+		want []string // Generations 0 to 5 from a single cell, top to bottom
+	}{
+		{"W30", []string{
+			".......#.......",
+			"......###......",
+			".....##..#.....",
+			"....##.####....",
+			"...##..#...#...",
+			"..##.####.###..",
+		}},
+		{"W110", []string{
+			".......#.......",
+			"......##.......",
+			".....###.......",
+			"....##.#.......",
+			"...#####.......",
+			"..##...#.......",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := ParseRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			width, height := len(tt.want[0]), len(tt.want)
+			u := NewUniverse(width, height)
+			u.SetRule(rule)
+			cells := rule.(*WolframRule).Seed(width, height, false)
+			u.Load(cells, blankColors(cells))
+			for i := 1; i < height; i++ {
+				u.Step()
+			}
+			for y, want := range tt.want {
+				var row strings.Builder
+				for x := 0; x < width; x++ {
+					if u.Alive(x, y) {
+						row.WriteByte('#')
+					} else {
+						row.WriteByte('.')
+					}
+				}
+				if row.String() != want {
+					t.Errorf("row %d is %s, want %s", y, row.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestWolframRoundTrip(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"W30", "W30"},
+		{"w110,k2,r1", "W110"},
+		{"W3485,K3", "W3485,K3"},
+		{"T1599,K3", "T1599,K3"},
+		{"t20,r2", "T20,R2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := ParseRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rule.String() != tt.want {
+				t.Errorf("written as %q, want %q", rule.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWolframErrors(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"Wx", "invalid code 'x'"},
+		{"W-1", "invalid code '-1'"},
+		{"W256", "code must be less than 2^8"},
+		{"T16", "code must be less than 2^4"},
+		{"W7625597484987,K3", "code must be less than 3^27"},
+		{"W30,K1", "state count must be between 2 and 256"},
+		{"T30,K257", "state count must be between 2 and 256"},
+		{"W30,R0", "range must be between 1 and 8"},
+		{"T30,R9", "range must be between 1 and 8"},
+		{"W30,K3,R8", "too large a table"},
+		{"W30,Q2", "unknown part 'Q2'"},
+		{"W30,K2,K3", "'K' given twice"},
+		{"W30,Kx", "invalid part 'Kx'"},
+		{"W30,", "empty part"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			_, err := ParseRule(tt.rule)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWolframTiles(t *testing.T) {
+	// Rule 1 turns empty stretches of the line on and off, and every row
+	// above it scrolls, so no tile stays quiet for long
+	for _, rule := range []string{"W30", "W1", "T1599,K3", "W90,R3"} {
+		t.Run(rule, func(t *testing.T) {
+			r, err := ParseRule(rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertStepsMatch(t, r, r.(*WolframRule).Seed(100, 70, true))
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jared-wallace/gol/engine"
+)
+
+// universeFlags are the flags the headless commands set up their universe
+// with.
+type universeFlags struct {
+	pattern, rule, colors *string
+	width, height         *int
+	seed                  *uint64
+}
+
+// addUniverseFlags defines the flags that set up the universe on fs.
+func addUniverseFlags(fs *flag.FlagSet) *universeFlags {
+	return &universeFlags{
+		pattern: fs.String("pattern", "random", "pattern to load from patterns/, or random for a random soup"),
+		rule:    fs.String("rule", "B3/S23", "rule for patterns that do not name one"),
+		colors:  fs.String("colors", "random", "color scheme"),
+		width:   fs.Int("width", 320, "board width in cells"),
+		height:  fs.Int("height", 256, "board height in cells"),
+		seed:    fs.Uint64("seed", 0, "seed for the chances of stochastic rules (0 picks one at random)"),
+	}
+}
+
+// load creates the universe the flags describe.
+func (f *universeFlags) load() (*engine.Universe, error) {
+	if *f.width < 1 || *f.height < 1 {
+		return nil, fmt.Errorf("board size must be at least 1x1")
+	}
+	rule, err := engine.ParseRule(*f.rule)
+	if err != nil {
+		return nil, err
+	}
+	scheme, err := engine.ColorSchemeByName(*f.colors)
+	if err != nil {
+		return nil, err
+	}
+	u := engine.NewUniverse(engine.BoardSize(rule, *f.width, *f.height))
+	if *f.seed != 0 {
+		u.SetSeed(*f.seed)
+	}
+	if err := u.LoadPattern(*f.pattern, rule); err != nil {
+		return nil, err
+	}
+	u.SetColorScheme(scheme)
+	return u, nil
+}
+
+// runScreenshot implements the screenshot command, which runs a pattern for
+// some generations and saves the board as a PNG file, without a window.
+func runScreenshot(args []string) error {
+	fs := flag.NewFlagSet("screenshot", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s screenshot [flags] out.png\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	universe := addUniverseFlags(fs)
+	generations := fs.Int("generations", 0, "generations to run before the screenshot")
+	cellSize := fs.Int("cell", 4, "pixels per cell")
+	grid := fs.Bool("grid", false, "draw grid lines between cells of at least 3 pixels")
+	hud := fs.Bool("hud", false, "print the rule, color scheme, generation, population and status in the top-left corner")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	u, err := universe.load()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < *generations; i++ {
+		u.Step()
+	}
+	img := u.Render(engine.RenderOptions{CellSize: *cellSize, Grid: *grid, HUD: *hud})
+	return engine.WritePNG(fs.Arg(0), img)
+}
+
+// runRender implements the render command, which runs a pattern and saves
+// its generations as an animated GIF or PNG, without a window.
+func runRender(args []string) error {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s render [flags] out.gif|out.png\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	universe := addUniverseFlags(fs)
+	frames := fs.Int("frames", 100, "frames to record")
+	every := fs.Int("every", 1, "generations between frames")
+	cellSize := fs.Int("cell", 4, "pixels per cell")
+	grid := fs.Bool("grid", false, "draw grid lines between cells of at least 3 pixels")
+	crop := fs.Bool("crop", true, "crop frames to the box the live cells stay within")
+	delay := fs.Duration("delay", 100*time.Millisecond, "time each frame is shown")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *frames < 1 || *every < 1 {
+		return fmt.Errorf("-frames and -every must be at least 1")
+	}
+
+	path := fs.Arg(0)
+	var apng bool
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gif":
+	case ".png", ".apng":
+		apng = true
+	default:
+		return fmt.Errorf("unknown animation format '%s', use .gif, .png or .apng", filepath.Ext(path))
+	}
+
+	u, err := universe.load()
+	if err != nil {
+		return err
+	}
+	recorder := engine.NewRecorder(engine.DefaultMaxRecordingFrames, engine.DefaultMaxRecordingBytes)
+	for i := 0; i < *frames; i++ {
+		if i > 0 {
+			for j := 0; j < *every; j++ {
+				u.Step()
+			}
+		}
+		err := recorder.Capture(u)
+		if errors.Is(err, engine.ErrRecordingFull) {
+			fmt.Fprintf(os.Stderr, "Recording stopped, %v\n", err)
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	opts := engine.AnimationOptions{CellSize: *cellSize, Grid: *grid, Crop: *crop, Delay: *delay}
+	if apng {
+		err = recorder.WriteAPNG(file, opts)
+	} else {
+		err = recorder.WriteGIF(file, opts)
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// runFrames implements the frames command, which runs a pattern and writes
+// its generations as frames of a fixed size, for a video encoder: numbered
+// PNG files, or a raw RGB or Y4M stream to a file or stdout.
+func runFrames(args []string) error {
+	fs := flag.NewFlagSet("frames", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s frames [flags] out\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "out is a directory or a file name with a number verb such as frames/%%05d.png for png,\nand a file or - for stdout for rgb and y4m\n")
+		fs.PrintDefaults()
+	}
+	universe := addUniverseFlags(fs)
+	frames := fs.Int("frames", 100, "frames to write")
+	every := fs.Int("every", 1, "generations between frames")
+	format := fs.String("format", "png", "png for numbered PNG files, rgb for raw 24-bit RGB or y4m for YUV4MPEG2")
+	size := fs.String("size", "1280x720", "frame size in pixels, even for y4m")
+	zoom := fs.Float64("zoom", 0, "pixels per cell, below 1 to zoom out further (0 zooms to fit the board, or the live cells with -follow)")
+	follow := fs.Bool("follow", false, "keep the live cells in the middle of the frame")
+	fps := fs.Int("fps", 30, "frame rate written into y4m streams")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *frames < 1 || *every < 1 {
+		return fmt.Errorf("-frames and -every must be at least 1")
+	}
+	if *zoom < 0 || *fps < 1 {
+		return fmt.Errorf("-zoom must not be negative and -fps must be at least 1")
+	}
+	var width, height int
+	if _, err := fmt.Sscanf(*size, "%dx%d", &width, &height); err != nil || width < 1 || height < 1 {
+		return fmt.Errorf("invalid frame size '%s', use WIDTHxHEIGHT", *size)
+	}
+
+	// Each frame goes to the next PNG file or to the stream
+	var writeFrame func(i int, img *image.RGBA) error
+	var finish func() error
+	if *format == "png" {
+		name := fs.Arg(0)
+		if !strings.Contains(name, "%") {
+			if err := os.MkdirAll(name, 0o755); err != nil {
+				return err
+			}
+			name = filepath.Join(name, "frame-%05d.png")
+		}
+		writeFrame = func(i int, img *image.RGBA) error { return engine.WritePNG(fmt.Sprintf(name, i), img) }
+		finish = func() error { return nil }
+	} else {
+		videoFormat, err := engine.ParseVideoFormat(*format)
+		if err != nil {
+			return err
+		}
+		out := os.Stdout
+		if fs.Arg(0) != "-" {
+			if out, err = os.Create(fs.Arg(0)); err != nil {
+				return err
+			}
+		}
+		buffered := bufio.NewWriter(out)
+		video := engine.NewVideoWriter(buffered, videoFormat, *fps)
+		writeFrame = func(i int, img *image.RGBA) error { return video.WriteFrame(img) }
+		finish = func() error {
+			err := buffered.Flush()
+			if out != os.Stdout {
+				if closeErr := out.Close(); err == nil {
+					err = closeErr
+				}
+			}
+			return err
+		}
+	}
+
+	u, err := universe.load()
+	if err != nil {
+		return err
+	}
+	camera := &engine.Camera{Width: width, Height: height, Zoom: *zoom, Follow: *follow}
+	for i := 0; i < *frames; i++ {
+		if i > 0 {
+			for j := 0; j < *every; j++ {
+				u.Step()
+			}
+		}
+		if err := writeFrame(i, camera.Frame(u)); err != nil {
+			finish()
+			return err
+		}
+	}
+	return finish()
+}
@@ -1,17 +1,72 @@
 package main
 
 import (
+	"flag"
+	"log"
+	"os"
+
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/jared-wallace/gol/engine"
 )
 
 // main initializes and runs the game.
 func main() {
+	// Commands that run without a window
+	if len(os.Args) > 1 {
+		commands := map[string]func([]string) error{
+			"screenshot": runScreenshot,
+			"render":     runRender,
+			"frames":     runFrames,
+			"keys":       func([]string) error { return engine.WriteDefaultKeymap(os.Stdout) },
+		}
+		if run, ok := commands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
+	autoStop := flag.Bool("autostop", false, "stop ticking once the board dies out, settles or starts repeating")
+	colors := flag.String("colors", "random", "color scheme: random, age, births-deaths, heat, monochrome, inherit-majority, inherit-average, immigration or quadlife")
+	rule := flag.String("rule", "B3/S23", "rule for patterns that do not name one, e.g. B36/S23, B2/S/C3 (Brian's Brain), 345/2/4 (Star Wars), W30 (a 1D rule) or the name of a .rule file in rules/")
+	randomLine := flag.Bool("random-line", false, "start one-dimensional rules such as W30 from a random line instead of a single cell")
+	seed := flag.Uint64("seed", 0, "seed for the chances of stochastic rules and asynchronous updates (0 picks one at random)")
+	update := flag.String("update", "sync", "which cells update every generation: sync (all), fraction (each with the -fraction chance) or sequential (random cells one after the other)")
+	fraction := flag.Float64("fraction", 0.5, "chance that a cell updates in a generation under -update fraction")
+	grid := flag.Bool("grid", false, "draw grid lines between cells (toggle with F2)")
+	gridCell := flag.Int("grid-cell", 6, "smallest cell size in pixels grid lines are drawn at")
+	keys := flag.String("keys", "", "JSON file rebinding keys, such as one started from the output of the keys command")
+	workers := flag.Int("workers", 0, "number of goroutines computing each generation (0 uses GOMAXPROCS)")
+	flag.Parse()
+
+	engine.SetWorkers(*workers)
+
 	// Initial grid size
 	initialGridWidth, initialGridHeight := 320, 256 // Adjusted for better performance
 
 	// Create a new game instance
 	game := engine.NewGame(initialGridWidth, initialGridHeight)
+	game.SetStopOnSettle(*autoStop)
+	game.SetGrid(*grid, *gridCell)
+	if *keys != "" {
+		if err := game.LoadKeymap(*keys); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := game.SetColorScheme(*colors); err != nil {
+		log.Fatal(err)
+	}
+	if *seed != 0 {
+		game.Universe().SetSeed(*seed)
+	}
+	if err := game.SetUpdateMode(*update, *fraction); err != nil {
+		log.Fatal(err)
+	}
+	game.SetRandomLine(*randomLine)
+	if err := game.SetRule(*rule); err != nil {
+		log.Fatal(err)
+	}
 
 	// Configure Ebiten window
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
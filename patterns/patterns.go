@@ -63,12 +63,12 @@ func loadPatternNames(dir string) ([]string, error) {
 	return patternNames, nil
 }
 
-func RandomConfig(height, width int) ([][]bool, [][]color.RGBA, string) {
+func RandomConfig(height, width int) ([][]uint8, [][]color.RGBA, string) {
 	cells, colors := initializeBoard(height, width)
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
 			if rand.Float64() < 0.2 { // 20% chance to be alive
-				cells[y][x] = true
+				cells[y][x] = 1
 				colors[y][x] = randomColor()
 			}
 		}
@@ -77,7 +77,7 @@ func RandomConfig(height, width int) ([][]bool, [][]color.RGBA, string) {
 }
 
 // GetConfig loads the specified pattern by index
-func (pg *PatternGenerator) GetConfig(idx int) ([][]bool, [][]color.RGBA, string, error) {
+func (pg *PatternGenerator) GetConfig(idx int) ([][]uint8, [][]color.RGBA, string, error) {
 	if idx < 0 || idx >= len(pg.patterns) {
 		return nil, nil, "", fmt.Errorf("pattern index %d out of range", idx)
 	}
@@ -96,16 +96,27 @@ func (pg *PatternGenerator) GetPatternCount() int {
 	return len(pg.patterns)
 }
 
+// GetPatternName returns the name of the pattern at idx. Index 0 is the random configuration.
+func (pg *PatternGenerator) GetPatternName(idx int) string {
+	return pg.patterns[idx]
+}
+
 // SetHW sets the height and width of the board
 func (pg *PatternGenerator) SetHW(height int, width int) {
 	pg.height = height
 	pg.width = width
 }
 
-func LoadPatternConfig(height, width int, patternName string) ([][]bool, [][]color.RGBA, string, error) {
-	cells, colors := initializeBoard(height, width)
-	midX, midY := width/2, height/2
+// Pattern is a pattern from the library, with its live cells relative to its top-left corner.
+type Pattern struct {
+	Name          string
+	Width, Height int
+	Cells         []PatternParser.Coordinate
+	Rule          string // Rule named by the pattern file, empty if it names none
+}
 
+// LoadPattern reads the named pattern from the patterns directory.
+func LoadPattern(patternName string) (*Pattern, error) {
 	// Try to open .txt file first, if not found, try .rle
 	var filePath string
 	if _, err := os.Stat(fmt.Sprintf("patterns/%s.txt", patternName)); err == nil {
@@ -115,50 +126,153 @@ func LoadPatternConfig(height, width int, patternName string) ([][]bool, [][]col
 	} else if _, err := os.Stat(fmt.Sprintf("patterns/%s.mc", patternName)); err == nil {
 		filePath = fmt.Sprintf("patterns/%s.mc", patternName)
 	} else {
-		return nil, nil, "", fmt.Errorf("pattern file for '%s' not found", patternName)
+		return nil, fmt.Errorf("pattern file for '%s' not found", patternName)
 	}
 
 	var coordinates []PatternParser.Coordinate
+	var rule string
 	var err error
 	if strings.HasSuffix(filePath, ".txt") {
 		// Read and parse the plaintext pattern file
 		coordinates, err = PatternParser.ReadPatternFromFile(filePath)
 		if err != nil {
-			return nil, nil, "", fmt.Errorf("failed to read pattern '%s': %v", patternName, err)
+			return nil, fmt.Errorf("failed to read pattern '%s': %v", patternName, err)
 		}
 	} else if strings.HasSuffix(filePath, ".rle") {
 		// Read and parse the RLE pattern file
-		coordinates, _, _, err = PatternParser.ReadRLEPatternFromFile(filePath)
+		var rle *PatternParser.RLEPattern
+		rle, err = readRLEFile(filePath)
 		if err != nil {
-			return nil, nil, "", fmt.Errorf("failed to read RLE pattern '%s': %v", patternName, err)
+			return nil, fmt.Errorf("failed to read RLE pattern '%s': %v", patternName, err)
 		}
+		coordinates, rule = rle.Cells, rle.Rule
 	} else if strings.HasSuffix(filePath, ".mc") {
 		// Read and parse the MC pattern file
 		coordinates, _, _, err = PatternParser.ReadMCMacrocellFromFile(filePath)
 		if err != nil {
-			return nil, nil, "", fmt.Errorf("failed to read MC pattern '%s': %v", patternName, err)
+			return nil, fmt.Errorf("failed to read MC pattern '%s': %v", patternName, err)
 		}
 	} else {
-		return nil, nil, "", fmt.Errorf("unknown file extension for pattern '%s'", patternName)
+		return nil, fmt.Errorf("unknown file extension for pattern '%s'", patternName)
+	}
+
+	p := &Pattern{Name: patternName, Cells: coordinates, Rule: rule}
+	for _, c := range coordinates {
+		if c.X >= p.Width {
+			p.Width = c.X + 1
+		}
+		if c.Y >= p.Height {
+			p.Height = c.Y + 1
+		}
+	}
+	return p, nil
+}
+
+// readRLEFile reads an RLE pattern file, keeping the rule from its header.
+func readRLEFile(filePath string) (*PatternParser.RLEPattern, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+	return PatternParser.ReadRLE(file)
+}
+
+// Bounds returns the bounding box of the live cells of the pattern. All
+// values are -1 if the pattern has no live cells.
+func (p *Pattern) Bounds() (minX, minY, maxX, maxY int) {
+	minX, minY, maxX, maxY = -1, -1, -1, -1
+	for _, c := range p.Cells {
+		if minX < 0 || c.X < minX {
+			minX = c.X
+		}
+		if minY < 0 || c.Y < minY {
+			minY = c.Y
+		}
+		if c.X > maxX {
+			maxX = c.X
+		}
+		if c.Y > maxY {
+			maxY = c.Y
+		}
+	}
+	return minX, minY, maxX, maxY
+}
+
+// Size returns the width and height of the bounding box of the live cells.
+func (p *Pattern) Size() (int, int) {
+	minX, minY, maxX, maxY := p.Bounds()
+	if minX < 0 {
+		return 0, 0
+	}
+	return maxX - minX + 1, maxY - minY + 1
+}
+
+// Fits reports whether the live cells of the pattern fit on a board of the given size.
+func (p *Pattern) Fits(height, width int) bool {
+	w, h := p.Size()
+	return w <= width && h <= height
+}
+
+// PatternTooLargeError reports a pattern that does not fit on the board.
+type PatternTooLargeError struct {
+	Name                    string
+	Width, Height           int
+	BoardWidth, BoardHeight int
+}
+
+func (e *PatternTooLargeError) Error() string {
+	return fmt.Sprintf("pattern '%s' is %dx%d but the board is only %dx%d; cells outside the board were dropped",
+		e.Name, e.Width, e.Height, e.BoardWidth, e.BoardHeight)
+}
+
+// PatternBoard builds a board with the pattern centered on its bounding box.
+// Cells that fall outside the board are dropped rather than wrapped, and a
+// *PatternTooLargeError is returned alongside the board when that happens.
+func PatternBoard(height, width int, p *Pattern) ([][]uint8, [][]color.RGBA, error) {
+	cells, colors := initializeBoard(height, width)
+	minX, minY, _, _ := p.Bounds()
+	w, h := p.Size()
+
+	// Set the alive cells on the board with the bounding box centered
+	setAliveCells(cells, colors, p.Cells, (width-w)/2-minX, (height-h)/2-minY, width, height)
+
+	if !p.Fits(height, width) {
+		return cells, colors, &PatternTooLargeError{Name: p.Name, Width: w, Height: h, BoardWidth: width, BoardHeight: height}
 	}
+	return cells, colors, nil
+}
 
-	// Convert to [][2]int
-	coordPairs := PatternParser.ParsePattern(coordinates)
+// GetPattern loads the pattern at idx from the library. Index 0 is the
+// random configuration, which has no pattern.
+func (pg *PatternGenerator) GetPattern(idx int) (*Pattern, error) {
+	if idx <= 0 || idx >= len(pg.patterns) {
+		return nil, fmt.Errorf("pattern index %d does not name a library pattern", idx)
+	}
+	return LoadPattern(pg.patterns[idx])
+}
 
-	// Set the alive cells on the board
-	setAliveCells(cells, colors, coordPairs, midX, midY, width, height)
+// LoadPatternConfig loads the named pattern centered on a board of the given
+// size. A pattern larger than the board is cropped, and the returned error is
+// a *PatternTooLargeError.
+func LoadPatternConfig(height, width int, patternName string) ([][]uint8, [][]color.RGBA, string, error) {
+	p, err := LoadPattern(patternName)
+	if err != nil {
+		return nil, nil, "", err
+	}
 
-	return cells, colors, patternName, nil
+	cells, colors, err := PatternBoard(height, width, p)
+	return cells, colors, patternName, err
 }
 
 // initializeBoard creates a new board with all cells dead and colors set to default.
-func initializeBoard(height, width int) ([][]bool, [][]color.RGBA) {
-	cells := make([][]bool, height)
+func initializeBoard(height, width int) ([][]uint8, [][]color.RGBA) {
+	cells := make([][]uint8, height)
 	colors := make([][]color.RGBA, height)
 	defaultColor := color.RGBA{A: 255}
 
 	for i := range cells {
-		cells[i] = make([]bool, width)
+		cells[i] = make([]uint8, width)
 		colors[i] = make([]color.RGBA, width)
 		for j := range colors[i] {
 			colors[i][j] = defaultColor
@@ -177,13 +291,17 @@ func randomColor() color.RGBA {
 	}
 }
 
-// setAliveCells sets the specified cells to alive and assigns them random colors.
-func setAliveCells(cells [][]bool, colors [][]color.RGBA, positions [][2]int, offsetX, offsetY int, width, height int) {
+// setAliveCells sets the specified cells to their states and assigns them
+// random colors. Cells that land outside the board are skipped.
+func setAliveCells(cells [][]uint8, colors [][]color.RGBA, positions []PatternParser.Coordinate, offsetX, offsetY int, width, height int) {
 	for _, pos := range positions {
-		x := (pos[0] + offsetX + width) % width
-		y := (pos[1] + offsetY + height) % height
+		x := pos.X + offsetX
+		y := pos.Y + offsetY
+		if x < 0 || x >= width || y < 0 || y >= height {
+			continue
+		}
 
-		cells[y][x] = true
+		cells[y][x] = uint8(max(pos.State, 1))
 		colors[y][x] = randomColor()
 	}
 }
@@ -0,0 +1,77 @@
+package patterns
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jared-wallace/gol/pkg/PatternParser"
+)
+
+// liveCells returns the coordinates of the live cells of a board, row by row.
+func liveCells(cells [][]uint8) []PatternParser.Coordinate {
+	var live []PatternParser.Coordinate
+	for y, row := range cells {
+		for x, state := range row {
+			if state != 0 {
+				live = append(live, PatternParser.Coordinate{X: x, Y: y})
+			}
+		}
+	}
+	return live
+}
+
+func TestPatternBoard(t *testing.T) {
+	// A glider offset from the origin, as patterns often are
+	glider := &Pattern{Name: "glider", Cells: []PatternParser.Coordinate{{X: 6, Y: 4}, {X: 7, Y: 5}, {X: 5, Y: 6}, {X: 6, Y: 6}, {X: 7, Y: 6}}}
+
+	tests := []struct {
+		name          string
+		pattern       *Pattern
+		width, height int
+		want          []PatternParser.Coordinate
+		tooLarge      bool
+	}{
+		{"centered", glider, 9, 7,
+			[]PatternParser.Coordinate{{X: 4, Y: 2}, {X: 5, Y: 3}, {X: 3, Y: 4}, {X: 4, Y: 4}, {X: 5, Y: 4}}, false},
+		{"rounded toward the top left", glider, 10, 8,
+			[]PatternParser.Coordinate{{X: 4, Y: 2}, {X: 5, Y: 3}, {X: 3, Y: 4}, {X: 4, Y: 4}, {X: 5, Y: 4}}, false},
+		{"exact fit", glider, 3, 3,
+			[]PatternParser.Coordinate{{X: 1, Y: 0}, {X: 2, Y: 1}, {X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2}}, false},
+		{"cropped on the right", glider, 2, 3,
+			[]PatternParser.Coordinate{{X: 1, Y: 0}, {X: 0, Y: 2}, {X: 1, Y: 2}}, true},
+		{"empty", &Pattern{Name: "empty"}, 4, 4, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cells, colors, err := PatternBoard(tt.height, tt.width, tt.pattern)
+			if len(cells) != tt.height || len(cells[0]) != tt.width || len(colors) != tt.height {
+				t.Fatalf("board is %dx%d, want %dx%d", len(cells[0]), len(cells), tt.width, tt.height)
+			}
+			got := liveCells(cells)
+			if len(got) != len(tt.want) {
+				t.Fatalf("live cells %v, want %v", got, tt.want)
+			}
+			// Compare as sets, since the board lists cells row by row
+			want := make(map[PatternParser.Coordinate]bool)
+			for _, c := range tt.want {
+				want[c] = true
+			}
+			for _, c := range got {
+				if !want[c] {
+					t.Fatalf("live cells %v, want %v", got, tt.want)
+				}
+			}
+
+			var tooLarge *PatternTooLargeError
+			if errors.As(err, &tooLarge) != tt.tooLarge {
+				t.Fatalf("error %v, want a PatternTooLargeError: %t", err, tt.tooLarge)
+			}
+			if tt.tooLarge {
+				want := PatternTooLargeError{Name: "glider", Width: 3, Height: 3, BoardWidth: tt.width, BoardHeight: tt.height}
+				if *tooLarge != want {
+					t.Errorf("error %+v, want %+v", *tooLarge, want)
+				}
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package PatternParser
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strconv"
@@ -13,6 +14,9 @@ import (
 type Coordinate struct {
 	X int
 	Y int
+	// State is the state of the cell in multi-state patterns, counting from
+	// 1. Two-state cells leave it at 0, which means the same as 1.
+	State int
 }
 
 // ReadPatternFromFile reads a plaintext Game of Life pattern from a file
@@ -61,8 +65,6 @@ func ParsePattern(coordinates []Coordinate) [][2]int {
 // and returns a slice of Coordinates where each Coordinate represents
 // a live cell ('o') in the pattern, along with the xMax and yMax.
 func ReadRLEPatternFromFile(filePath string) ([]Coordinate, int, int, error) {
-	var coordinates []Coordinate
-
 	// Open the file
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -70,9 +72,42 @@ func ReadRLEPatternFromFile(filePath string) ([]Coordinate, int, int, error) {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	return ReadRLEPattern(file)
+}
+
+// RLEPattern is a pattern read from RLE, with the size and rule given in its header.
+type RLEPattern struct {
+	Cells         []Coordinate
+	Width, Height int
+	Rule          string // Empty if the header names no rule
+}
+
+// ReadRLEPattern reads an RLE format Game of Life pattern from r and returns
+// a slice of Coordinates where each Coordinate represents a live cell ('o')
+// in the pattern, along with the xMax and yMax.
+func ReadRLEPattern(r io.Reader) ([]Coordinate, int, int, error) {
+	p, err := ReadRLE(r)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return p.Cells, p.Width, p.Height, nil
+}
+
+// rleHeaderRegex matches the size and optional rule of an RLE header line.
+var rleHeaderRegex = regexp.MustCompile(`x\s*=\s*(\d+)\s*,\s*y\s*=\s*(\d+)(?:\s*,\s*rule\s*=\s*(\S+))?`)
+
+// ReadRLE reads an RLE pattern from r. Besides the two-state 'b' and 'o'
+// tags it understands the multi-state tags of Generations and other rules:
+// '.' for state 0, 'A' to 'X' for states 1 to 24, and 'p' to 'y' followed by
+// a letter for the states above.
+func ReadRLE(r io.Reader) (*RLEPattern, error) {
+	var coordinates []Coordinate
+	var err error
+
+	scanner := bufio.NewScanner(r)
 
 	var xSize, ySize int
+	var rule string
 	headerParsed := false
 
 	// Read the header
@@ -85,27 +120,27 @@ func ReadRLEPatternFromFile(filePath string) ([]Coordinate, int, int, error) {
 		}
 		if strings.HasPrefix(line, "x") {
 			// Parse the header line
-			headerRegex := regexp.MustCompile(`x\s*=\s*(\d+)\s*,\s*y\s*=\s*(\d+)`)
-			matches := headerRegex.FindStringSubmatch(line)
+			matches := rleHeaderRegex.FindStringSubmatch(line)
 			if len(matches) >= 3 {
 				xSize, err = strconv.Atoi(matches[1])
 				if err != nil {
-					return nil, 0, 0, fmt.Errorf("invalid x size in header: %v", err)
+					return nil, fmt.Errorf("invalid x size in header: %v", err)
 				}
 				ySize, err = strconv.Atoi(matches[2])
 				if err != nil {
-					return nil, 0, 0, fmt.Errorf("invalid y size in header: %v", err)
+					return nil, fmt.Errorf("invalid y size in header: %v", err)
 				}
+				rule = matches[3]
 				headerParsed = true
 			} else {
-				return nil, 0, 0, fmt.Errorf("invalid header line: %s", line)
+				return nil, fmt.Errorf("invalid header line: %s", line)
 			}
 			break // Exit after parsing header
 		}
 	}
 
 	if !headerParsed {
-		return nil, 0, 0, fmt.Errorf("RLE header not found in file")
+		return nil, fmt.Errorf("RLE header not found in file")
 	}
 
 	// Read the pattern data
@@ -121,7 +156,7 @@ func ReadRLEPatternFromFile(filePath string) ([]Coordinate, int, int, error) {
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, 0, 0, fmt.Errorf("error reading file: %v", err)
+		return nil, fmt.Errorf("error reading file: %v", err)
 	}
 
 	patternData := strings.Join(patternLines, "")
@@ -129,45 +164,69 @@ func ReadRLEPatternFromFile(filePath string) ([]Coordinate, int, int, error) {
 	patternData = strings.ReplaceAll(patternData, " ", "")
 	// Now parse the pattern data
 
+	pattern := &RLEPattern{Width: xSize, Height: ySize, Rule: rule}
 	x, y := 0, 0
 	count := 0
 	number := ""
 
 	for i := 0; i < len(patternData); i++ {
 		c := patternData[i]
-		switch c {
-		case 'b', 'o', '$', '!':
-			if number != "" {
-				count, err = strconv.Atoi(number)
-				if err != nil {
-					return nil, 0, 0, fmt.Errorf("invalid number in pattern data: %v", err)
-				}
-				number = ""
-			} else {
-				count = 1
+		switch {
+		case c >= '0' && c <= '9':
+			number += string(c)
+			continue
+		case c == 'b', c == 'o', c == '.', c == '$', c == '!', c >= 'A' && c <= 'X', c >= 'p' && c <= 'y':
+		default:
+			return nil, fmt.Errorf("unexpected character '%c' in pattern data", c)
+		}
+
+		if number != "" {
+			count, err = strconv.Atoi(number)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number in pattern data: %v", err)
 			}
+			number = ""
+		} else {
+			count = 1
+		}
 
-			switch c {
-			case 'b':
-				x += count
-			case 'o':
-				for j := 0; j < count; j++ {
-					coordinates = append(coordinates, Coordinate{X: x, Y: y})
-					x++
-				}
-			case '$':
-				y += count
-				x = 0
-			case '!':
-				// End of pattern
-				return coordinates, xSize, ySize, nil
+		state := 0 // Dead cells
+		switch {
+		case c == '$':
+			y += count
+			x = 0
+			continue
+		case c == '!':
+			// End of pattern
+			pattern.Cells = coordinates
+			return pattern, nil
+		case c == 'o':
+			state = 1
+		case c >= 'A' && c <= 'X':
+			state = int(c-'A') + 1
+		case c >= 'p' && c <= 'y':
+			// A prefix selects the block of 24 states the next letter counts in
+			if i+1 >= len(patternData) || patternData[i+1] < 'A' || patternData[i+1] > 'X' {
+				return nil, fmt.Errorf("state prefix '%c' is not followed by a letter", c)
 			}
-		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-			number += string(c)
-		default:
-			return nil, 0, 0, fmt.Errorf("unexpected character '%c' in pattern data", c)
+			i++
+			state = int(c-'p'+1)*24 + int(patternData[i]-'A') + 1
+		}
+
+		if state == 0 {
+			x += count
+			continue
+		}
+		for j := 0; j < count; j++ {
+			cell := Coordinate{X: x, Y: y}
+			if c != 'o' {
+				cell.State = state
+			}
+			coordinates = append(coordinates, cell)
+			x++
 		}
 	}
 
-	return coordinates, xSize, ySize, nil
+	pattern.Cells = coordinates
+	return pattern, nil
 }
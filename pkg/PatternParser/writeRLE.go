@@ -0,0 +1,102 @@
+package PatternParser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// rleLineLength is the maximum length of a pattern data line written by WriteRLE
+const rleLineLength = 70
+
+// WriteRLE writes the live cells at the given coordinates as an RLE pattern
+// of the given size. Coordinates outside the size are ignored. Patterns with
+// cells in states above 1 are written with the multi-state tags read by ReadRLE.
+func WriteRLE(w io.Writer, coordinates []Coordinate, width, height int, rule string) error {
+	grid := make([][]int, height)
+	for i := range grid {
+		grid[i] = make([]int, width)
+	}
+	multiState := false
+	for _, c := range coordinates {
+		if c.X >= 0 && c.X < width && c.Y >= 0 && c.Y < height {
+			grid[c.Y][c.X] = max(c.State, 1)
+			if c.State > 1 {
+				multiState = true
+			}
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	if rule != "" {
+		fmt.Fprintf(bw, "x = %d, y = %d, rule = %s\n", width, height, rule)
+	} else {
+		fmt.Fprintf(bw, "x = %d, y = %d\n", width, height)
+	}
+
+	lineLength := 0
+	emit := func(count int, tag string) {
+		token := tag
+		if count > 1 {
+			token = strconv.Itoa(count) + token
+		}
+		if lineLength+len(token) > rleLineLength {
+			bw.WriteByte('\n')
+			lineLength = 0
+		}
+		bw.WriteString(token)
+		lineLength += len(token)
+	}
+
+	pendingRows := 0 // End-of-row markers not yet written
+	for y, row := range grid {
+		// Dead cells at the end of a row are implied
+		last := len(row) - 1
+		for last >= 0 && row[last] == 0 {
+			last--
+		}
+		if last < 0 {
+			if y > 0 {
+				pendingRows++
+			}
+			continue
+		}
+		if y > 0 {
+			pendingRows++
+		}
+		if pendingRows > 0 {
+			emit(pendingRows, "$")
+			pendingRows = 0
+		}
+
+		for x := 0; x <= last; {
+			run := 1
+			for x+run <= last && row[x+run] == row[x] {
+				run++
+			}
+			emit(run, stateTag(row[x], multiState))
+			x += run
+		}
+	}
+	emit(1, "!")
+	bw.WriteByte('\n')
+
+	return bw.Flush()
+}
+
+// stateTag returns the RLE tag for a cell state.
+func stateTag(state int, multiState bool) string {
+	switch {
+	case !multiState && state == 0:
+		return "b"
+	case !multiState:
+		return "o"
+	case state == 0:
+		return "."
+	case state <= 24:
+		return string(rune('A' + state - 1))
+	default:
+		return string(rune('p'+(state-1)/24-1)) + string(rune('A'+(state-1)%24))
+	}
+}
@@ -0,0 +1,60 @@
+package PatternParser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRLERoundTrip(t *testing.T) {
+	// A run of 100 live cells forces the data onto several lines
+	var longRun []Coordinate
+	for x := 0; x < 100; x++ {
+		longRun = append(longRun, Coordinate{X: x, Y: 1})
+	}
+
+	tests := []struct {
+		name          string
+		coordinates   []Coordinate
+		width, height int
+		want          string // Written RLE, unchecked if empty
+	}{
+		{"empty", nil, 4, 3, "x = 4, y = 3, rule = B3/S23\n!\n"},
+		{"glider", []Coordinate{{X: 1, Y: 0}, {X: 2, Y: 1}, {X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2}}, 3, 3,
+			"x = 3, y = 3, rule = B3/S23\nbo$2bo$3o!\n"},
+		{"blank rows", []Coordinate{{X: 0, Y: 1}, {X: 3, Y: 4}}, 5, 6,
+			"x = 5, y = 6, rule = B3/S23\n$o3$3bo!\n"},
+		{"long run", longRun, 100, 2, ""},
+		{"multi-state", []Coordinate{{X: 0, Y: 0, State: 1}, {X: 1, Y: 0, State: 2}, {X: 2, Y: 0, State: 2}, {X: 0, Y: 1, State: 24}, {X: 2, Y: 1, State: 25}}, 3, 2,
+			"x = 3, y = 2, rule = B3/S23\nA2B$X.pA!\n"},
+		{"high states", []Coordinate{{X: 0, Y: 0, State: 48}, {X: 1, Y: 0, State: 49}, {X: 1, Y: 1, State: 255}}, 2, 2,
+			"x = 2, y = 2, rule = B3/S23\npXqA$.yO!\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := WriteRLE(&b, tt.coordinates, tt.width, tt.height, "B3/S23"); err != nil {
+				t.Fatal(err)
+			}
+			if tt.want != "" && b.String() != tt.want {
+				t.Errorf("wrote %q, want %q", b.String(), tt.want)
+			}
+			for _, line := range strings.Split(b.String(), "\n") {
+				if len(line) > rleLineLength && !strings.HasPrefix(line, "x") {
+					t.Errorf("line of %d characters, longer than %d", len(line), rleLineLength)
+				}
+			}
+
+			coordinates, width, height, err := ReadRLEPattern(strings.NewReader(b.String()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if width != tt.width || height != tt.height {
+				t.Errorf("read size %dx%d, want %dx%d", width, height, tt.width, tt.height)
+			}
+			if !reflect.DeepEqual(coordinates, tt.coordinates) {
+				t.Errorf("read %v, want %v", coordinates, tt.coordinates)
+			}
+		})
+	}
+}